@@ -3,52 +3,247 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/conductorone/baton-sdk/pkg/cli"
-	"github.com/spf13/cobra"
+	"github.com/conductorone/baton-sdk/pkg/field"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/spf13/viper"
 )
 
 // config defines the external configuration required for the connector to run.
 type config struct {
-	cli.BaseConfig `mapstructure:",squash"` // Puts the base config options in the same place as the connector options
-
 	AccessToken string `mapstructure:"token"`
 	Username    string `mapstructure:"username"`
 	Password    string `mapstructure:"password"`
+	SessionAuth bool   `mapstructure:"session-auth"`
+
+	// AccessTokenFile and PasswordFile are read on every request instead of once, so rotated secrets are picked up.
+	AccessTokenFile string `mapstructure:"token-file"`
+	PasswordFile    string `mapstructure:"password-file"`
 
 	Unsafe      bool     `mapstructure:"unsafe"`
 	Verbose     bool     `mapstructure:"verbose"`
 	Cloud       bool     `mapstructure:"cloud"`
 	Deployments []string `mapstructure:"deployments"`
+	OfflinePath string   `mapstructure:"offline-path"`
+	LockoutRole string   `mapstructure:"lockout-role"`
+
+	DeploymentsFile string `mapstructure:"deployments-file"`
+	Discover        bool   `mapstructure:"discover"`
+
+	CABundle   string `mapstructure:"ca-bundle"`
+	ClientCert string `mapstructure:"client-cert"`
+	ClientKey  string `mapstructure:"client-key"`
+
+	KnowledgeObjectOwner string `mapstructure:"knowledge-object-owner"`
+	DryRun               bool   `mapstructure:"dry-run"`
+
+	ACS    bool   `mapstructure:"acs"`
+	ACSURL string `mapstructure:"acs-url"`
+
+	MaxRetries            int           `mapstructure:"max-retries"`
+	RetryBackoff          time.Duration `mapstructure:"retry-backoff"`
+	MaxConcurrentRequests int           `mapstructure:"max-concurrent-requests"`
 }
 
-// validateConfig is run after the configuration is loaded, and should return an error if it isn't valid.
+// validateConfig is run after the configuration is loaded, and checks what the constraints of configuration can't express.
 func validateConfig(ctx context.Context, cfg *config) error {
-	accessTokenNotSet := (cfg.AccessToken == "")
-	basicNotSet := (cfg.Username == "" || cfg.Password == "")
+	if cfg.OfflinePath != "" {
+		if cfg.DeploymentsFile != "" {
+			return fmt.Errorf("offline mode can't be combined with a deployments file")
+		}
+
+		if cfg.Discover {
+			return fmt.Errorf("offline mode can't discover other nodes")
+		}
+
+		if cfg.Cloud {
+			return fmt.Errorf("offline mode can't be combined with cloud mode")
+		}
+
+		if len(cfg.Deployments) > 1 {
+			return fmt.Errorf("offline mode syncs a single deployment")
+		}
+
+		return nil
+	}
+
+	// Secret files are read again on every request, but should be readable from the start.
+	for _, path := range []string{cfg.AccessTokenFile, cfg.PasswordFile} {
+		if path == "" {
+			continue
+		}
+
+		if _, err := splunk.FileSecret(path).Value(); err != nil {
+			return err
+		}
+	}
+
+	accessTokenNotSet := (cfg.AccessToken == "" && cfg.AccessTokenFile == "")
+	basicNotSet := (cfg.Username == "" || (cfg.Password == "" && cfg.PasswordFile == ""))
+
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("max retries can't be negative")
+	}
+
+	if cfg.RetryBackoff < 0 {
+		return fmt.Errorf("retry backoff can't be negative")
+	}
+
+	// Deployments of a deployments file bring their own credentials, checked when the file is loaded.
+	if cfg.DeploymentsFile != "" {
+		if len(cfg.Deployments) > 0 {
+			return fmt.Errorf("deployments can't be listed both in flags and in a deployments file")
+		}
+	} else {
+		if accessTokenNotSet && basicNotSet {
+			return fmt.Errorf("either an access token or username and password must be provided")
+		}
+
+		if cfg.SessionAuth && basicNotSet {
+			return fmt.Errorf("session authentication requires username and password")
+		}
+
+		if cfg.Cloud && len(cfg.Deployments) == 0 {
+			return fmt.Errorf("cloud mode requires at least one deployment")
+		}
 
-	if accessTokenNotSet && basicNotSet {
-		return fmt.Errorf("either an access token or username and password must be provided")
+		if cfg.ACS && !cfg.Cloud {
+			return fmt.Errorf("the admin config service is only available in cloud mode")
+		}
 	}
 
-	if cfg.Cloud && len(cfg.Deployments) == 0 {
-		return fmt.Errorf("cloud mode requires at least one deployment")
+	// The admin config service only accepts JWT authentication tokens.
+	if cfg.ACS && accessTokenNotSet {
+		return fmt.Errorf("the admin config service requires an access token")
 	}
 
 	return nil
 }
 
-// cmdFlags sets the cmdFlags required for the connector.
-func cmdFlags(cmd *cobra.Command) {
-	cmd.PersistentFlags().String("token", "", "The Splunk access token used to connect to the Splunk API. ($BATON_TOKEN)")
-	cmd.PersistentFlags().String("username", "", "Username of user used to connect to the Splunk API. ($BATON_USERNAME)")
-	cmd.PersistentFlags().String("password", "", "Password of user used to connect to the Splunk API. ($BATON_PASSWORD)")
-	cmd.PersistentFlags().Bool("unsafe", false, "Allow insecure TLS connections to Splunk. ($BATON_UNSAFE)")
-	cmd.PersistentFlags().Bool("verbose", false, "Enable listing verbose entitlements for Role capabilities. ($BATON_VERBOSE)")
-	cmd.PersistentFlags().Bool("cloud", false, "Switches to cloud API endpoints. ($BATON_CLOUD)")
-	cmd.PersistentFlags().StringSlice(
+var (
+	tokenField     = field.StringField("token", field.WithDescription("The Splunk access token used to connect to the Splunk API."))
+	usernameField  = field.StringField("username", field.WithDescription("Username of user used to connect to the Splunk API."))
+	passwordField  = field.StringField("password", field.WithDescription("Password of user used to connect to the Splunk API."))
+	tokenFileField = field.StringField(
+		"token-file",
+		field.WithDescription("Path to a file holding the Splunk access token, read on every request so rotated tokens are picked up."),
+	)
+	passwordFileField = field.StringField(
+		"password-file",
+		field.WithDescription("Path to a file holding the password, read on every request so rotated passwords are picked up."),
+	)
+	sessionAuthField = field.BoolField(
+		"session-auth",
+		field.WithDescription("Log in with username and password to get a session key instead of sending them on every request."),
+	)
+	unsafeField   = field.BoolField("unsafe", field.WithDescription("Allow insecure TLS connections to Splunk."))
+	caBundleField = field.StringField(
+		"ca-bundle",
+		field.WithDescription("Path to PEM certificates of the CAs that signed the certificates of Splunk deployments, trusted besides the system ones."),
+	)
+	clientCertField = field.StringField(
+		"client-cert",
+		field.WithDescription("Path to the PEM client certificate presented to deployments requiring mutual TLS."),
+	)
+	clientKeyField = field.StringField("client-key", field.WithDescription("Path to the PEM key of the client certificate."))
+	verboseField   = field.BoolField("verbose", field.WithDescription("Enable listing verbose entitlements for Role capabilities and applications."))
+	cloudField     = field.BoolField("cloud", field.WithDescription("Switches to cloud API endpoints."))
+	acsField       = field.BoolField(
+		"acs",
+		field.WithDescription("Sync apps, indexes, tokens, HEC tokens and IP allowlists of cloud deployments through the Admin Config Service."),
+	)
+	acsURLField = field.StringField(
+		"acs-url",
+		field.WithDefaultValue(acs.DefaultBaseURL),
+		field.WithDescription("Base URL of the Admin Config Service, only changed to point at a local fake of it."),
+	)
+	deploymentsField = field.StringSliceField(
 		"deployments",
-		[]string{},
-		"Limit syncing to specific deployments by specifying cloud deployment names or IP addresses of on-premise deployments. ($BATON_DEPLOYMENTS)",
+		field.WithDescription("Limit syncing to specific deployments by specifying cloud deployment names, IP addresses of on-premise deployments or full URLs such as https://splunk.example.com:8443."),
+	)
+	deploymentsFileField = field.StringField(
+		"deployments-file",
+		field.WithDescription("Path to a YAML or JSON file configuring each deployment with its own URL, credentials and TLS settings."),
+	)
+	discoverField = field.BoolField(
+		"discover",
+		field.WithDescription("Sync every member of the search head clusters and indexer clusters of the deployments, sending writes to the search head cluster captain."),
+	)
+	maxRetriesField = field.IntField(
+		"max-retries",
+		field.WithDefaultValue(splunk.DefaultMaxRetries),
+		field.WithDescription("Number of times requests failing with a transient error are retried."),
+	)
+	retryBackoffField = field.StringField(
+		"retry-backoff",
+		field.WithDefaultValue(splunk.DefaultRetryBackoff.String()),
+		field.WithDescription("Wait before the first retry, doubled on each following one."),
 	)
+	maxConcurrentRequestsField = field.IntField(
+		"max-concurrent-requests",
+		field.WithDefaultValue(splunk.DefaultMaxConcurrentRequests),
+		field.WithDescription("Maximum number of requests in flight to each deployment, 0 for no limit."),
+	)
+	lockoutRoleField = field.StringField(
+		"lockout-role",
+		field.WithDescription("Role without capabilities that users are moved to when their login is revoked."),
+	)
+	knowledgeObjectOwnerField = field.StringField(
+		"knowledge-object-owner",
+		field.WithDescription("User that takes over saved searches, alerts and dashboards of users before they are locked or deleted."),
+	)
+	dryRunField = field.BoolField(
+		"dry-run",
+		field.WithDescription("Only list the knowledge objects that would be reassigned, without locking or deleting users."),
+	)
+	offlinePathField = field.StringField(
+		"offline-path",
+		field.WithDescription("Sync from a Splunk etc directory or a tarball of it instead of the REST API."),
+	)
+)
+
+// configuration defines the flags of the connector, checked by the SDK before validateConfig runs.
+var configuration = field.NewConfiguration(
+	[]field.SchemaField{
+		tokenField,
+		usernameField,
+		passwordField,
+		tokenFileField,
+		passwordFileField,
+		sessionAuthField,
+		unsafeField,
+		caBundleField,
+		clientCertField,
+		clientKeyField,
+		verboseField,
+		cloudField,
+		acsField,
+		acsURLField,
+		deploymentsField,
+		deploymentsFileField,
+		discoverField,
+		maxRetriesField,
+		retryBackoffField,
+		maxConcurrentRequestsField,
+		lockoutRoleField,
+		knowledgeObjectOwnerField,
+		dryRunField,
+		offlinePathField,
+	},
+	field.FieldsMutuallyExclusive(tokenField, tokenFileField),
+	field.FieldsMutuallyExclusive(passwordField, passwordFileField),
+	field.FieldsRequiredTogether(clientCertField, clientKeyField),
+	field.FieldsDependentOn([]field.SchemaField{dryRunField}, []field.SchemaField{knowledgeObjectOwnerField}),
+)
+
+// loadConfig reads the values of the flags, environment variables and config file gathered by the SDK.
+func loadConfig(v *viper.Viper) (*config, error) {
+	cfg := &config{}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	return cfg, nil
 }
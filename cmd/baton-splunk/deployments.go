@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/conductorone/baton-splunk/pkg/connector"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"gopkg.in/yaml.v3"
+)
+
+// deploymentsFile is the layout of the file set with `--deployments-file`. JSON files are read
+// the same way, since JSON is valid YAML.
+type deploymentsFile struct {
+	Deployments []deploymentEntry `yaml:"deployments"`
+}
+
+// deploymentEntry configures one deployment. Keys are named after the flags they override, and
+// settings left out fall back to those flags. Secrets can be given inline, or read from an
+// environment variable or a file with the `-env` and `-file` variants of their key. Files are read
+// on every request, like the ones given with `--token-file` and `--password-file`.
+type deploymentEntry struct {
+	Name        string `yaml:"name"`
+	DisplayName string `yaml:"display-name"`
+	URL         string `yaml:"url"`
+	Cloud       *bool  `yaml:"cloud"`
+
+	Token        string `yaml:"token"`
+	TokenEnv     string `yaml:"token-env"`
+	TokenFile    string `yaml:"token-file"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordEnv  string `yaml:"password-env"`
+	PasswordFile string `yaml:"password-file"`
+	SessionAuth  bool   `yaml:"session-auth"`
+
+	Unsafe     *bool  `yaml:"unsafe"`
+	CABundle   string `yaml:"ca-bundle"`
+	ClientCert string `yaml:"client-cert"`
+	ClientKey  string `yaml:"client-key"`
+}
+
+// loadDeployments reads the deployments file and resolves the settings and credentials of each deployment.
+func loadDeployments(cfg *config) ([]connector.DeploymentConfig, error) {
+	file, err := os.Open(cfg.DeploymentsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deployments file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	// misspelled keys would otherwise silently fall back to the flags
+	decoder.KnownFields(true)
+
+	var content deploymentsFile
+	if err := decoder.Decode(&content); err != nil {
+		return nil, fmt.Errorf("failed to parse deployments file %s: %w", cfg.DeploymentsFile, err)
+	}
+
+	if len(content.Deployments) == 0 {
+		return nil, fmt.Errorf("deployments file %s lists no deployments", cfg.DeploymentsFile)
+	}
+
+	deployments := make([]connector.DeploymentConfig, 0, len(content.Deployments))
+	for i, entry := range content.Deployments {
+		deployment, err := entry.resolve(cfg)
+		if err != nil {
+			name := entry.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+
+			return nil, fmt.Errorf("deployment %s: %w", name, err)
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, nil
+}
+
+func (e *deploymentEntry) resolve(cfg *config) (connector.DeploymentConfig, error) {
+	deployment := connector.DeploymentConfig{
+		Name:        e.Name,
+		DisplayName: e.DisplayName,
+		Cloud:       cfg.Cloud,
+
+		Unsafe:         cfg.Unsafe,
+		CABundlePath:   cfg.CABundle,
+		ClientCertPath: cfg.ClientCert,
+		ClientKeyPath:  cfg.ClientKey,
+	}
+
+	if e.URL != "" {
+		name, baseURL, err := splunk.ParseDeployment(e.URL)
+		if err != nil {
+			return deployment, err
+		}
+
+		if baseURL == "" {
+			return deployment, fmt.Errorf("url %s must include the scheme, such as https://", e.URL)
+		}
+
+		// deployments given only by URL are named after their host and port, like on the command line
+		if deployment.Name == "" {
+			deployment.Name = name
+		}
+
+		deployment.URL = baseURL
+	}
+
+	if deployment.Name == "" {
+		return deployment, fmt.Errorf("either a name or a url must be provided")
+	}
+
+	if e.Cloud != nil {
+		deployment.Cloud = *e.Cloud
+	}
+
+	if e.Unsafe != nil {
+		deployment.Unsafe = *e.Unsafe
+	}
+
+	if e.CABundle != "" {
+		deployment.CABundlePath = e.CABundle
+	}
+
+	if (e.ClientCert == "") != (e.ClientKey == "") {
+		return deployment, fmt.Errorf("client certificate and client key must be provided together")
+	}
+
+	if e.ClientCert != "" {
+		deployment.ClientCertPath = e.ClientCert
+		deployment.ClientKeyPath = e.ClientKey
+	}
+
+	token, err := resolveSecret("token", e.Token, e.TokenEnv, e.TokenFile)
+	if err != nil {
+		return deployment, err
+	}
+
+	password, err := resolveSecret("password", e.Password, e.PasswordEnv, e.PasswordFile)
+	if err != nil {
+		return deployment, err
+	}
+
+	basicNotSet := (e.Username == "" || password == nil)
+
+	if token == nil && basicNotSet {
+		return deployment, fmt.Errorf("either an access token or username and password must be provided")
+	}
+
+	if e.SessionAuth && basicNotSet {
+		return deployment, fmt.Errorf("session authentication requires username and password")
+	}
+
+	deployment.Auth = newAuthenticator(token, e.Username, password, e.SessionAuth)
+
+	return deployment, nil
+}
+
+// resolveSecret returns a secret given inline, through an environment variable or in a file, or nil when
+// none is set. At most one of them can be set. Files are read again on every request.
+func resolveSecret(key string, value string, env string, path string) (splunk.Secret, error) {
+	sources := 0
+	for _, source := range []string{value, env, path} {
+		if source != "" {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return nil, fmt.Errorf("only one of %s, %s-env and %s-file can be provided", key, key, key)
+	}
+
+	switch {
+	case env != "":
+		secret, ok := os.LookupEnv(env)
+		if !ok || secret == "" {
+			return nil, fmt.Errorf("environment variable %s of %s is not set", env, key)
+		}
+
+		return splunk.StaticSecret(secret), nil
+
+	case path != "":
+		secret := splunk.FileSecret(path)
+
+		// the file should be readable from the start, even if it is read again later
+		if _, err := secret.Value(); err != nil {
+			return nil, fmt.Errorf("failed to read %s file: %w", key, err)
+		}
+
+		return secret, nil
+	}
+
+	return newSecret(value, ""), nil
+}
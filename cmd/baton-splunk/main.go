@@ -2,15 +2,16 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"os"
 
-	"github.com/conductorone/baton-sdk/pkg/cli"
+	configSchema "github.com/conductorone/baton-sdk/pkg/config"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/types"
 	"github.com/conductorone/baton-splunk/pkg/connector"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
@@ -19,15 +20,13 @@ var version = "dev"
 func main() {
 	ctx := context.Background()
 
-	cfg := &config{}
-	cmd, err := cli.NewCmd(ctx, "baton-splunk", cfg, validateConfig, getConnector)
+	_, cmd, err := configSchema.DefineConfiguration(ctx, "baton-splunk", getConnector, configuration)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 
 	cmd.Version = version
-	cmdFlags(cmd)
 
 	err = cmd.Execute()
 	if err != nil {
@@ -36,24 +35,72 @@ func main() {
 	}
 }
 
-func constructAuth(cfg *config) string {
-	if cfg.AccessToken != "" {
-		return "Bearer " + cfg.AccessToken
+func constructAuth(cfg *config) splunk.Authenticator {
+	return newAuthenticator(
+		newSecret(cfg.AccessToken, cfg.AccessTokenFile),
+		cfg.Username,
+		newSecret(cfg.Password, cfg.PasswordFile),
+		cfg.SessionAuth,
+	)
+}
+
+// newSecret returns the secret read from path when one is set, so that it is read on every request,
+// and the secret given as value otherwise. It returns nil when neither is set.
+func newSecret(value string, path string) splunk.Secret {
+	if path != "" {
+		return splunk.FileSecret(path)
 	}
 
-	if cfg.Username != "" {
-		credentials := fmt.Sprintf("%s:%s", cfg.Username, cfg.Password)
-		encodedCredentials := base64.StdEncoding.EncodeToString([]byte(credentials))
+	if value != "" {
+		return splunk.StaticSecret(value)
+	}
 
-		return "Basic " + encodedCredentials
+	return nil
+}
+
+// newAuthenticator picks the authentication method from the credentials available, preferring the access token.
+func newAuthenticator(accessToken splunk.Secret, username string, password splunk.Secret, sessionAuth bool) splunk.Authenticator {
+	if accessToken != nil {
+		return splunk.NewTokenAuth(accessToken)
 	}
 
-	return ""
+	if username != "" {
+		if password == nil {
+			password = splunk.StaticSecret("")
+		}
+
+		if sessionAuth {
+			return splunk.NewSessionAuth(username, password)
+		}
+
+		return splunk.NewBasicAuth(username, password)
+	}
+
+	return splunk.StaticAuth("")
 }
 
-func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, error) {
+func getConnector(ctx context.Context, v *viper.Viper) (types.ConnectorServer, error) {
 	l := ctxzap.Extract(ctx)
 
+	cfg, err := loadConfig(v)
+	if err != nil {
+		l.Error("error loading configuration", zap.Error(err))
+		return nil, err
+	}
+
+	if err := validateConfig(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	var deployments []connector.DeploymentConfig
+	if cfg.DeploymentsFile != "" {
+		deployments, err = loadDeployments(cfg)
+		if err != nil {
+			l.Error("error loading deployments file", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	splunkConnector, err := connector.New(
 		ctx,
 		constructAuth(cfg),
@@ -61,6 +108,28 @@ func getConnector(ctx context.Context, cfg *config) (types.ConnectorServer, erro
 			Unsafe:  cfg.Unsafe,
 			Verbose: cfg.Verbose,
 			Cloud:   cfg.Cloud,
+
+			CABundlePath:   cfg.CABundle,
+			ClientCertPath: cfg.ClientCert,
+			ClientKeyPath:  cfg.ClientKey,
+
+			LockoutRole: cfg.LockoutRole,
+
+			KnowledgeObjectOwner: cfg.KnowledgeObjectOwner,
+			DryRun:               cfg.DryRun,
+
+			ACS:      cfg.ACS,
+			ACSURL:   cfg.ACSURL,
+			ACSToken: newSecret(cfg.AccessToken, cfg.AccessTokenFile),
+
+			Deployments: deployments,
+			Discover:    cfg.Discover,
+
+			OfflinePath: cfg.OfflinePath,
+
+			MaxRetries:            cfg.MaxRetries,
+			RetryBackoff:          cfg.RetryBackoff,
+			MaxConcurrentRequests: cfg.MaxConcurrentRequests,
 		},
 		cfg.Deployments,
 	)
@@ -0,0 +1,243 @@
+package acs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+const (
+	// DefaultBaseURL is the Admin Config Service of Splunk Cloud. Other base URLs are only useful for testing.
+	DefaultBaseURL = "https://admin.splunk.com"
+
+	AppsURL        = "/%s/adminconfig/v2/apps/victoria"
+	IndexesURL     = "/%s/adminconfig/v2/indexes"
+	HECTokensURL   = "/%s/adminconfig/v2/inputs/http-event-collectors"
+	IPAllowlistURL = "/%s/adminconfig/v2/access/%s/ipallowlists"
+	TokensURL      = "/%s/adminconfig/v2/tokens"
+	TokenURL       = "/%s/adminconfig/v2/tokens/%s"
+
+	// maxErrorBodySize caps how much of an error response is read looking for its message.
+	maxErrorBodySize = 64 * 1024
+)
+
+// IPAllowlistFeatures are the features of a stack that have their own IP allowlist.
+var IPAllowlistFeatures = []string{
+	"search-api",
+	"search-ui",
+	"hec",
+	"s2s",
+	"idm-api",
+	"idm-ui",
+}
+
+// Client talks to the Admin Config Service of a single Splunk Cloud stack. Unlike the management
+// API, the service is reachable without asking support to allowlist the connector.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	stack      string
+	token      splunk.Secret
+}
+
+func NewClient(httpClient *http.Client, baseURL string, stack string, token splunk.Secret) *Client {
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		stack:      stack,
+		token:      token,
+	}
+}
+
+// Stack returns the name of the stack the client is bound to.
+func (c *Client) Stack() string {
+	return c.stack
+}
+
+func (c *Client) createURL(endpoint string, args ...interface{}) string {
+	return c.baseURL + fmt.Sprintf(endpoint, append([]interface{}{url.PathEscape(c.stack)}, args...)...)
+}
+
+// GetApps returns the apps installed on the stack.
+func (c *Client) GetApps(ctx context.Context, getAppsVars splunk.PaginationVars) ([]App, string, error) {
+	var response appsResponse
+
+	err := c.get(ctx, c.createURL(AppsURL), &response, &getAppsVars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return response.Apps, nextPage(&getAppsVars, len(response.Apps)), nil
+}
+
+// GetIndexes returns the indexes of the stack.
+func (c *Client) GetIndexes(ctx context.Context, getIndexesVars splunk.PaginationVars) ([]Index, string, error) {
+	var response []Index
+
+	err := c.get(ctx, c.createURL(IndexesURL), &response, &getIndexesVars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return response, nextPage(&getIndexesVars, len(response)), nil
+}
+
+// GetHECTokens returns the HTTP Event Collector tokens of the stack.
+func (c *Client) GetHECTokens(ctx context.Context, getHECTokensVars splunk.PaginationVars) ([]HECToken, string, error) {
+	var response hecTokensResponse
+
+	err := c.get(ctx, c.createURL(HECTokensURL), &response, &getHECTokensVars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return response.HECTokens, nextPage(&getHECTokensVars, len(response.HECTokens)), nil
+}
+
+// GetIPAllowlist returns the subnets allowed to reach one feature of the stack.
+func (c *Client) GetIPAllowlist(ctx context.Context, feature string) ([]string, error) {
+	var response ipAllowlistResponse
+
+	err := c.get(ctx, c.createURL(IPAllowlistURL, url.PathEscape(feature)), &response, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Subnets, nil
+}
+
+// GetTokens returns the JWT authentication tokens of the stack.
+func (c *Client) GetTokens(ctx context.Context, getTokensVars splunk.PaginationVars) ([]Token, string, error) {
+	var response []Token
+
+	err := c.get(ctx, c.createURL(TokensURL), &response, &getTokensVars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return response, nextPage(&getTokensVars, len(response)), nil
+}
+
+// GetToken returns one specific JWT authentication token of the stack.
+func (c *Client) GetToken(ctx context.Context, tokenID string) (*Token, error) {
+	var response Token
+
+	err := c.get(ctx, c.createURL(TokenURL, url.PathEscape(tokenID)), &response, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// DeleteToken deletes a JWT authentication token of the stack. The service has no way to disable tokens.
+func (c *Client) DeleteToken(ctx context.Context, tokenID string) error {
+	return c.do(ctx, http.MethodDelete, c.createURL(TokenURL, url.PathEscape(tokenID)), nil, nil)
+}
+
+// nextPage returns the offset of the next page, or nothing once a page comes back short.
+// The service doesn't report totals, so a full last page costs one more empty request.
+func nextPage(paginationVars *splunk.PaginationVars, count int) string {
+	if paginationVars.Limit == 0 || count < paginationVars.Limit {
+		return ""
+	}
+
+	offset, _ := strconv.Atoi(paginationVars.Page)
+
+	return strconv.Itoa(offset + count)
+}
+
+func (c *Client) get(ctx context.Context, urlAddress string, resourceResponse interface{}, paginationVars *splunk.PaginationVars) error {
+	return c.do(ctx, http.MethodGet, urlAddress, resourceResponse, paginationVars)
+}
+
+// do sends a request to the service, decoding its response into resourceResponse unless it is nil.
+func (c *Client) do(
+	ctx context.Context,
+	method string,
+	urlAddress string,
+	resourceResponse interface{},
+	paginationVars *splunk.PaginationVars,
+) error {
+	req, err := http.NewRequestWithContext(ctx, method, urlAddress, nil)
+	if err != nil {
+		return err
+	}
+
+	if paginationVars != nil {
+		query := req.URL.Query()
+
+		if paginationVars.Limit != 0 {
+			query.Set("count", strconv.Itoa(paginationVars.Limit))
+		}
+
+		if paginationVars.Page != "" {
+			query.Set("offset", paginationVars.Page)
+		}
+
+		req.URL.RawQuery = query.Encode()
+	}
+
+	token, err := c.token.Value()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	rawResponse, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer rawResponse.Body.Close()
+
+	if rawResponse.StatusCode >= 300 {
+		return newAPIError(rawResponse)
+	}
+
+	if resourceResponse == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(rawResponse.Body).Decode(resourceResponse); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newAPIError builds a splunk.APIError from a failed response, so that failures of the service
+// map to the same gRPC codes as failures of the management API.
+func newAPIError(rawResponse *http.Response) *splunk.APIError {
+	apiErr := &splunk.APIError{
+		StatusCode: rawResponse.StatusCode,
+	}
+
+	if rawResponse.Request != nil {
+		apiErr.Method = rawResponse.Request.Method
+		apiErr.Endpoint = rawResponse.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(io.LimitReader(rawResponse.Body, maxErrorBodySize))
+	if err != nil {
+		return apiErr
+	}
+
+	var response errorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return apiErr
+	}
+
+	apiErr.Type = response.Code
+	apiErr.Text = response.Message
+
+	return apiErr
+}
@@ -0,0 +1,236 @@
+package acs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, token splunk.Secret) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewClient(server.Client(), server.URL, "my-stack", token)
+}
+
+func TestGetTokensPagesByOffset(t *testing.T) {
+	tokens := []Token{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}}
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/my-stack/adminconfig/v2/tokens" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		end := offset + count
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+
+		_ = json.NewEncoder(w).Encode(tokens[offset:end])
+	}, splunk.StaticSecret("jwt"))
+
+	var (
+		ids   []string
+		pages []string
+	)
+
+	page := ""
+	for {
+		pageTokens, nextPage, err := client.GetTokens(context.Background(), splunk.PaginationVars{Limit: 2, Page: page})
+		if err != nil {
+			t.Fatalf("GetTokens: %v", err)
+		}
+
+		for _, token := range pageTokens {
+			ids = append(ids, token.ID)
+		}
+
+		pages = append(pages, nextPage)
+
+		if nextPage == "" {
+			break
+		}
+
+		page = nextPage
+	}
+
+	if got, want := len(ids), len(tokens); got != want {
+		t.Fatalf("got %d tokens, want %d", got, want)
+	}
+
+	wantPages := []string{"2", "4", ""}
+	if len(pages) != len(wantPages) {
+		t.Fatalf("got next pages %v, want %v", pages, wantPages)
+	}
+
+	for i := range wantPages {
+		if pages[i] != wantPages[i] {
+			t.Fatalf("got next pages %v, want %v", pages, wantPages)
+		}
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		vars  splunk.PaginationVars
+		count int
+		want  string
+	}{
+		{name: "full first page", vars: splunk.PaginationVars{Limit: 50}, count: 50, want: "50"},
+		{name: "full later page", vars: splunk.PaginationVars{Limit: 50, Page: "100"}, count: 50, want: "150"},
+		{name: "short page", vars: splunk.PaginationVars{Limit: 50, Page: "100"}, count: 12, want: ""},
+		{name: "empty page", vars: splunk.PaginationVars{Limit: 50, Page: "150"}, count: 0, want: ""},
+		{name: "no limit", vars: splunk.PaginationVars{}, count: 80, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := tt.vars
+			if got := nextPage(&vars, tt.count); got != tt.want {
+				t.Errorf("nextPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAppsDecodesErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":"403-forbidden","message":"the user is missing the sc_admin role"}`))
+	}, splunk.StaticSecret("jwt"))
+
+	_, _, err := client.GetApps(context.Background(), splunk.PaginationVars{Limit: 50})
+
+	var apiErr *splunk.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got error %v, want a *splunk.APIError", err)
+	}
+
+	if apiErr.StatusCode != http.StatusForbidden || apiErr.Method != http.MethodGet {
+		t.Errorf("got status %d and method %s", apiErr.StatusCode, apiErr.Method)
+	}
+
+	if apiErr.Endpoint != "/my-stack/adminconfig/v2/apps/victoria" {
+		t.Errorf("got endpoint %s", apiErr.Endpoint)
+	}
+
+	if apiErr.Type != "403-forbidden" || apiErr.Text != "the user is missing the sc_admin role" {
+		t.Errorf("got type %q and text %q", apiErr.Type, apiErr.Text)
+	}
+
+	if got := status.Code(err); got != codes.PermissionDenied {
+		t.Errorf("got code %s, want %s", got, codes.PermissionDenied)
+	}
+}
+
+func TestGetIndexesKeepsStatusOfUnreadableErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>bad gateway</html>"))
+	}, splunk.StaticSecret("jwt"))
+
+	_, _, err := client.GetIndexes(context.Background(), splunk.PaginationVars{Limit: 50})
+
+	var apiErr *splunk.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got error %v, want a *splunk.APIError", err)
+	}
+
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Text != "" {
+		t.Errorf("got status %d and text %q", apiErr.StatusCode, apiErr.Text)
+	}
+}
+
+func TestRequestsUseCurrentBearerToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var authorizations []string
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+
+		if got := r.Header.Get("Accept"); got != "application/json" {
+			t.Errorf("got Accept %q", got)
+		}
+
+		_, _ = w.Write([]byte(`{"subnets":["10.0.0.0/8"]}`))
+	}, splunk.FileSecret(path))
+
+	subnets, err := client.GetIPAllowlist(context.Background(), "search-api")
+	if err != nil {
+		t.Fatalf("GetIPAllowlist: %v", err)
+	}
+
+	if len(subnets) != 1 || subnets[0] != "10.0.0.0/8" {
+		t.Errorf("got subnets %v", subnets)
+	}
+
+	// rotated tokens are picked up by the next request
+	if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetIPAllowlist(context.Background(), "hec"); err != nil {
+		t.Fatalf("GetIPAllowlist: %v", err)
+	}
+
+	want := []string{"Bearer first", "Bearer second"}
+	if len(authorizations) != len(want) || authorizations[0] != want[0] || authorizations[1] != want[1] {
+		t.Errorf("got authorizations %v, want %v", authorizations, want)
+	}
+}
+
+func TestGetAndDeleteToken(t *testing.T) {
+	var requests []string
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":"a/b","user":"alice","status":"enabled"}`))
+	}, splunk.StaticSecret("jwt"))
+
+	token, err := client.GetToken(context.Background(), "a/b")
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+
+	if token.ID != "a/b" || token.User != "alice" {
+		t.Errorf("got token %+v", token)
+	}
+
+	if err := client.DeleteToken(context.Background(), "a/b"); err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+
+	want := []string{
+		"GET /my-stack/adminconfig/v2/tokens/a%2Fb",
+		"DELETE /my-stack/adminconfig/v2/tokens/a%2Fb",
+	}
+	if len(requests) != len(want) || requests[0] != want[0] || requests[1] != want[1] {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+}
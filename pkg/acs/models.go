@@ -0,0 +1,56 @@
+package acs
+
+// App is an app installed on a Splunk Cloud stack.
+type App struct {
+	AppID   string `json:"appID"`
+	Label   string `json:"label"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+type appsResponse struct {
+	Apps []App `json:"apps"`
+}
+
+// Index is an index of a Splunk Cloud stack.
+type Index struct {
+	Name           string `json:"name"`
+	DataType       string `json:"datatype"`
+	SearchableDays int    `json:"searchableDays"`
+	MaxDataSizeMB  int    `json:"maxDataSizeMB"`
+}
+
+// HECToken is an HTTP Event Collector token. The token value itself is never kept.
+type HECToken struct {
+	Spec struct {
+		Name           string   `json:"name"`
+		DefaultIndex   string   `json:"defaultIndex"`
+		AllowedIndexes []string `json:"allowedIndexes"`
+		Disabled       bool     `json:"disabled"`
+	} `json:"spec"`
+}
+
+type hecTokensResponse struct {
+	HECTokens []HECToken `json:"http-event-collectors"`
+}
+
+type ipAllowlistResponse struct {
+	Subnets []string `json:"subnets"`
+}
+
+// Token is a JWT authentication token of a Splunk Cloud stack.
+type Token struct {
+	ID         string `json:"id"`
+	User       string `json:"user"`
+	Audience   string `json:"audience"`
+	Status     string `json:"status"`
+	ExpiresOn  string `json:"expiresOn"`
+	NotBefore  string `json:"notBefore"`
+	LastUsed   string `json:"lastUsed"`
+	LastUsedIP string `json:"lastUsedIP"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
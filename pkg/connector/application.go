@@ -10,15 +10,19 @@ import (
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
 	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/acs"
 	"github.com/conductorone/baton-splunk/pkg/splunk"
 )
 
 const readPerm = "read"
 const writePerm = "write"
 
+// aclWildcard grants an ACL permission to every role.
+const aclWildcard = "*"
+
 type applicationResourceType struct {
 	resourceType *v2.ResourceType
-	client       *splunk.Client
+	clients      *clientRegistry
 
 	verbose bool
 }
@@ -34,12 +38,42 @@ func applicationResource(ctx context.Context, application *splunk.Application, p
 		return nil, fmt.Errorf("splunk-connector: %w", err)
 	}
 
-	displayName := titleCase(application.Name)
+	// Knowledge objects are only listed on on-premise deployments, so apps listed through the Admin Config Service don't have them.
+	return newApplicationResource(
+		application.Name,
+		applicationID,
+		parentResourceID,
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeSavedSearch.Id},
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeDashboard.Id},
+		),
+	)
+}
+
+// acsApplicationResource creates a new connector resource for an app listed by the Admin Config Service of a Splunk Cloud stack.
+func acsApplicationResource(ctx context.Context, app *acs.App, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	description := fmt.Sprintf("%s %s, %s", app.Label, app.Version, app.Status)
+
+	return newApplicationResource(app.AppID, app.AppID, parentResourceID, rs.WithDescription(description))
+}
+
+func newApplicationResource(name string, applicationID string, parentResourceID *v2.ResourceId, opts ...rs.ResourceOption) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: application %s is missing its deployment", name)
+	}
+
+	displayName := titleCase(name)
+
+	resourceOptions := []rs.ResourceOption{
+		rs.WithParentResourceID(parentResourceID),
+	}
+	resourceOptions = append(resourceOptions, opts...)
+
 	resource, err := rs.NewResource(
 		displayName,
 		resourceTypeApplication,
-		applicationID,
-		rs.WithParentResourceID(parentResourceID),
+		namespacedID(parentResourceID.Resource, applicationID),
+		resourceOptions...,
 	)
 	if err != nil {
 		return nil, err
@@ -49,16 +83,32 @@ func applicationResource(ctx context.Context, application *splunk.Application, p
 }
 
 func (a *applicationResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	if parentID != nil {
+	// Applications are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
 		return nil, "", nil, nil
 	}
 
+	client, err := a.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
 	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeApplication.Id})
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	applications, nextPage, err := a.client.GetApplications(
+	// Splunk Cloud stacks list their apps through the Admin Config Service.
+	if acsClient := a.clients.acsClient(client.Deployment()); acsClient != nil {
+		return a.listACSApplications(ctx, acsClient, parentID, bag)
+	}
+
+	// Without it, applications are only listed on on-premise deployments.
+	if client.IsCloudPlatform() {
+		return nil, "", nil, nil
+	}
+
+	applications, nextPage, err := client.GetApplications(
 		ctx,
 		splunk.PaginationVars{
 			Limit: ResourcesPageSize,
@@ -89,26 +139,63 @@ func (a *applicationResourceType) List(ctx context.Context, parentID *v2.Resourc
 	return rv, pageToken, nil, nil
 }
 
+func (a *applicationResourceType) listACSApplications(
+	ctx context.Context,
+	acsClient *acs.Client,
+	parentID *v2.ResourceId,
+	bag *pagination.Bag,
+) ([]*v2.Resource, string, annotations.Annotations, error) {
+	apps, nextPage, err := acsClient.GetApps(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list applications of stack %s: %w", acsClient.Stack(), err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(apps))
+	for _, app := range apps {
+		appCopy := app
+
+		ar, err := acsApplicationResource(ctx, &appCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, ar)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
 func (a *applicationResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
 	if !a.verbose {
 		return nil, "", nil, nil
 	}
 
-	grantableToUser := ent.WithGrantableTo(resourceTypeUser)
+	grantableToRole := ent.WithGrantableTo(resourceTypeRole)
 	entDescription := ent.WithDescription(fmt.Sprintf("%s Splunk application", resource.DisplayName))
 
 	var rv []*v2.Entitlement
 	rv = append(rv, ent.NewPermissionEntitlement(
 		resource,
 		readPerm,
-		grantableToUser,
+		grantableToRole,
 		entDescription,
 		ent.WithDisplayName(fmt.Sprintf("%s application READ", resource.DisplayName)),
 	))
 	rv = append(rv, ent.NewPermissionEntitlement(
 		resource,
 		writePerm,
-		grantableToUser,
+		grantableToRole,
 		entDescription,
 		ent.WithDisplayName(fmt.Sprintf("%s application WRITE", resource.DisplayName)),
 	))
@@ -116,74 +203,107 @@ func (a *applicationResourceType) Entitlements(_ context.Context, resource *v2.R
 	return rv, "", nil, nil
 }
 
-func (a *applicationResourceType) Grants(ctx context.Context, resource *v2.Resource, pt *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+// Grants resolves the application ACL to the roles it names, so users get access through their role membership.
+// Roles also get the access of the roles they import, and an ACL of `*` grants every role on the deployment.
+func (a *applicationResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	if !a.verbose {
 		return nil, "", nil, nil
 	}
 
-	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeUser.Id})
+	client, err := a.clients.clientFor(resource)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	application, err := a.client.GetApplication(ctx, resource.Id.Resource)
+	applicationName, err := objectName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	application, err := client.GetApplication(ctx, applicationName)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get application: %w", err)
 	}
 
 	applicationReadRoles, applicationWriteRoles := application.ACL.Perms.Read, application.ACL.Perms.Write
 
-	var rv []*v2.Grant
-	users, nextPage, err := a.client.GetUsers(
-		ctx,
-		splunk.PaginationVars{
-			Limit: ResourcesPageSize,
-			Page:  bag.PageToken(),
-		},
-	)
+	// an application that lists no roles in its ACL grants nothing
+	if len(applicationReadRoles) == 0 && len(applicationWriteRoles) == 0 {
+		return nil, "", nil, nil
+	}
+
+	roles, err := a.clients.cache.allRoles(ctx, client)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get users: %w", err)
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
 	}
 
-	pageToken, err := bag.NextToken(nextPage)
+	rv, err := aclGrants(ctx, client, resource, roles, applicationReadRoles, applicationWriteRoles)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	for _, user := range users {
-		userCopy := user
+	return rv, "", nil, nil
+}
+
+// aclGrants grants the read and write permissions of an ACL to the roles it lists, or to every role through `*`.
+// The grants are expanded to the members of each role, so roles importing a listed role inherit the permission.
+func aclGrants(
+	ctx context.Context,
+	client *splunk.Client,
+	resource *v2.Resource,
+	roles []splunk.Role,
+	readRoles []string,
+	writeRoles []string,
+) ([]*v2.Grant, error) {
+	var rv []*v2.Grant
+	for _, role := range roles {
+		if !containsRole(readRoles, role.Name) && !containsRole(writeRoles, role.Name) {
+			continue
+		}
 
-		ur, err := userResource(ctx, &userCopy, resource.ParentResourceId)
+		roleCopy := role
+		rr, err := roleResource(ctx, &roleCopy, deploymentResourceID(client.Deployment()))
 		if err != nil {
-			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build user resource: %w", err)
+			return nil, fmt.Errorf("splunk-connector: failed to build role resource: %w", err)
 		}
 
-		for _, role := range user.Content.Roles {
-			if containsRole(applicationReadRoles, role) {
-				rv = append(rv, grant.NewGrant(
-					resource,
-					readPerm,
-					ur.Id,
-				))
-			}
-
-			if containsRole(applicationWriteRoles, role) {
-				rv = append(rv, grant.NewGrant(
-					resource,
-					writePerm,
-					ur.Id,
-				))
-			}
+		if g, ok := aclGrant(resource, readPerm, readRoles, role.Name, rr); ok {
+			rv = append(rv, g)
+		}
+
+		if g, ok := aclGrant(resource, writePerm, writeRoles, role.Name, rr); ok {
+			rv = append(rv, g)
 		}
 	}
 
-	return rv, pageToken, nil, nil
+	return rv, nil
+}
+
+// aclGrant grants an ACL permission to a role listed in the ACL, or to any role through `*`,
+// in which case the grant records the pattern it matched.
+func aclGrant(resource *v2.Resource, permission string, aclRoles []string, roleName string, role *v2.Resource) (*v2.Grant, bool) {
+	if isResourcePresent(aclRoles, roleName) {
+		return roleMemberGrant(resource, permission, role), true
+	}
+
+	if !containsRole(aclRoles, roleName) {
+		return nil, false
+	}
+
+	return roleMemberGrant(
+		resource,
+		permission,
+		role,
+		grant.WithGrantMetadata(map[string]interface{}{
+			"matched_pattern": aclWildcard,
+		}),
+	), true
 }
 
-func applicationBuilder(client *splunk.Client, verbose bool) *applicationResourceType {
+func applicationBuilder(clients *clientRegistry, verbose bool) *applicationResourceType {
 	return &applicationResourceType{
 		resourceType: resourceTypeApplication,
-		client:       client,
+		clients:      clients,
 		verbose:      verbose,
 	}
 }
@@ -0,0 +1,290 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const capabilityAssigned = "assigned"
+
+type capabilityResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (c *capabilityResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return c.resourceType
+}
+
+// capabilityResource creates a new connector resource for a Splunk capability, annotated with its risk tier.
+func capabilityResource(ctx context.Context, capability string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: capability %s is missing its deployment", capability)
+	}
+
+	info := describeCapability(capability)
+
+	metadata, err := structpb.NewStruct(map[string]interface{}{
+		"capability": capability,
+		"risk_tier":  info.risk,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := rs.NewResource(
+		capability,
+		resourceTypeCapability,
+		namespacedID(parentResourceID.Resource, capability),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(fmt.Sprintf("%s (%s risk)", info.description, info.risk)),
+		rs.WithAnnotation(metadata),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (c *capabilityResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// Capabilities are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := c.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeCapability.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	capabilitiesEntry, nextPage, err := client.GetCapabilities(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list capabilities: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var rv []*v2.Resource
+	for _, capabilityEntry := range capabilitiesEntry {
+		for _, capability := range capabilityEntry.Content.Capabilities {
+			cr, err := capabilityResource(ctx, capability, parentID)
+			if err != nil {
+				return nil, "", nil, err
+			}
+
+			rv = append(rv, cr)
+		}
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (c *capabilityResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	entitlementOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeRole),
+		ent.WithDisplayName(fmt.Sprintf("%s capability", resource.DisplayName)),
+		ent.WithDescription(resource.Description),
+	}
+
+	rv = append(rv, ent.NewPermissionEntitlement(resource, capabilityAssigned, entitlementOptions...))
+
+	return rv, "", nil, nil
+}
+
+// Grants grants the capability to the roles holding it. The grants are expandable, so the users holding those roles
+// and the roles importing them get the capability through their role membership.
+func (c *capabilityResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	client, err := c.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	capability, err := objectName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, err := c.clients.cache.allRoles(ctx, client)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, role := range roles {
+		if !isResourcePresent(role.Content.Capabilities, capability) {
+			continue
+		}
+
+		roleCopy := role
+
+		rr, err := roleResource(ctx, &roleCopy, deploymentResourceID(client.Deployment()))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build role resource: %w", err)
+		}
+
+		rv = append(rv, roleMemberGrant(resource, capabilityAssigned, rr))
+	}
+
+	return rv, "", nil, nil
+}
+
+func (c *capabilityResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != resourceTypeRole.Id {
+		l.Warn(
+			"splunk-connector: only roles can be granted capabilities, users get them through their roles",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: only roles can be granted capabilities, users get them through their roles")
+	}
+
+	client, err := c.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	capability, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, grantRoleCapability(ctx, client, roleName, capability)
+}
+
+func (c *capabilityResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := grant.Entitlement
+	principal := grant.Principal
+
+	if principal.Id.ResourceType != resourceTypeRole.Id {
+		l.Warn(
+			"splunk-connector: only roles can have capabilities revoked, revoke the roles of the user instead",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: only roles can have capabilities revoked, revoke the roles of the user instead")
+	}
+
+	client, err := c.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	capability, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, revokeRoleCapability(ctx, client, roleName, capability)
+}
+
+// grantRoleCapability adds a capability to the capabilities of a role.
+func grantRoleCapability(ctx context.Context, client *splunk.Client, roleName string, capability string) error {
+	// get existing capabilities under role
+	role, err := client.GetRole(ctx, roleName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	// check if capability is already granted
+	if isResourcePresent(role.Content.Capabilities, capability) {
+		return fmt.Errorf("splunk-connector: capability %s already granted to role", capability)
+	}
+
+	// merge new capability into existing capabilities
+	role.Content.Capabilities = append(role.Content.Capabilities, capability)
+
+	// grant capability membership
+	err = client.UpdateRoleCapabilities(ctx, roleName, role.Content.Capabilities)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to grant capability membership: %w", err)
+	}
+
+	return nil
+}
+
+// revokeRoleCapability removes a capability from the capabilities of a role. Inherited capabilities
+// can only be revoked from the imported role they come from.
+func revokeRoleCapability(ctx context.Context, client *splunk.Client, roleName string, capability string) error {
+	// get existing capabilities under role
+	role, err := client.GetRole(ctx, roleName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	// check if capability is present in role's capabilities
+	if !isResourcePresent(role.Content.Capabilities, capability) {
+		// inherited capabilities can only be revoked from the imported role or by removing the import
+		if isResourcePresent(role.Content.ImportedCapabilities, capability) {
+			return fmt.Errorf(
+				"splunk-connector: capability %s is inherited by role %s through imported roles %s, revoke it there instead",
+				capability,
+				roleName,
+				strings.Join(role.Content.ImportedRoles, ", "),
+			)
+		}
+
+		return fmt.Errorf("splunk-connector: capability %s not present in role's capabilities", capability)
+	}
+
+	// remove new capability from existing capabilities
+	role.Content.Capabilities = removeResource(role.Content.Capabilities, capability)
+
+	// revoke capability membership
+	err = client.UpdateRoleCapabilities(ctx, roleName, role.Content.Capabilities)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to revoke capability membership: %w", err)
+	}
+
+	return nil
+}
+
+func capabilityBuilder(clients *clientRegistry) *capabilityResourceType {
+	return &capabilityResourceType{
+		resourceType: resourceTypeCapability,
+		clients:      clients,
+	}
+}
@@ -0,0 +1,193 @@
+package connector
+
+const (
+	capabilityRiskHigh    = "high"
+	capabilityRiskMedium  = "medium"
+	capabilityRiskLow     = "low"
+	capabilityRiskUnknown = "unknown"
+)
+
+// capabilityInfo describes a built-in Splunk capability and how much access it gives away.
+type capabilityInfo struct {
+	description string
+	risk        string
+}
+
+// capabilityCatalog holds the built-in capabilities worth a description in access reviews.
+// Capabilities missing from it, such as the ones added by apps, have an unknown risk.
+var capabilityCatalog = map[string]capabilityInfo{
+	// Capabilities that give control over the instance, its users or its data.
+	"admin_all_objects": {
+		description: "Access and modify any object of the instance, including other users' knowledge objects",
+		risk:        capabilityRiskHigh,
+	},
+	"change_authentication": {
+		description: "Change authentication settings, including LDAP and SAML configuration",
+		risk:        capabilityRiskHigh,
+	},
+	"delete_by_keyword": {
+		description: "Permanently hide events from search with the delete command",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_user": {
+		description: "Create, edit and delete users, including their roles and passwords",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_roles": {
+		description: "Create, edit and delete roles, and assign them to users",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_roles_grantable": {
+		description: "Create and edit roles limited to the capabilities the editor holds",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_tokens_all": {
+		description: "Create, edit and delete authentication tokens of any user",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_tokens_settings": {
+		description: "Enable or disable token authentication for the instance",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_token_http": {
+		description: "Create and edit HTTP Event Collector tokens",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_httpauths": {
+		description: "Edit and end user sessions",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_server": {
+		description: "Edit general server settings, such as ports and the server name",
+		risk:        capabilityRiskHigh,
+	},
+	"edit_scripted": {
+		description: "Create and edit scripted inputs, which run commands on the instance",
+		risk:        capabilityRiskHigh,
+	},
+	"install_apps": {
+		description: "Install, upgrade and remove apps",
+		risk:        capabilityRiskHigh,
+	},
+	"list_storage_passwords": {
+		description: "Read credentials stored by apps in the storage/passwords endpoint",
+		risk:        capabilityRiskHigh,
+	},
+	"restart_splunkd": {
+		description: "Restart the instance",
+		risk:        capabilityRiskHigh,
+	},
+
+	// Capabilities that change how data flows in or out, or that widen what users can see.
+	"edit_local_apps": {
+		description: "Edit the settings of installed apps",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_monitor": {
+		description: "Create and edit file and directory monitor inputs",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_tcp": {
+		description: "Create and edit TCP inputs",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_udp": {
+		description: "Create and edit UDP inputs",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_splunktcp": {
+		description: "Create and edit inputs receiving data from forwarders",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_forwarders": {
+		description: "Edit forwarding and receiving settings",
+		risk:        capabilityRiskMedium,
+	},
+	"edit_tokens_own": {
+		description: "Create and edit authentication tokens for the user's own account",
+		risk:        capabilityRiskMedium,
+	},
+	"list_tokens_all": {
+		description: "List authentication tokens of all users",
+		risk:        capabilityRiskMedium,
+	},
+	"indexes_edit": {
+		description: "Create, edit and remove indexes",
+		risk:        capabilityRiskMedium,
+	},
+	"embed_report": {
+		description: "Embed reports in pages outside of Splunk, without authentication",
+		risk:        capabilityRiskMedium,
+	},
+	"output_file": {
+		description: "Write search results to files on the instance",
+		risk:        capabilityRiskMedium,
+	},
+	"rest_apps_management": {
+		description: "Manage apps through the REST API",
+		risk:        capabilityRiskMedium,
+	},
+	"schedule_search": {
+		description: "Schedule searches and create alerts",
+		risk:        capabilityRiskMedium,
+	},
+	"schedule_rtsearch": {
+		description: "Schedule real-time searches",
+		risk:        capabilityRiskMedium,
+	},
+	"web_debug": {
+		description: "Access debugging endpoints of Splunk Web",
+		risk:        capabilityRiskMedium,
+	},
+
+	// Everyday search and read-only capabilities.
+	"search": {
+		description: "Run searches",
+		risk:        capabilityRiskLow,
+	},
+	"rtsearch": {
+		description: "Run real-time searches",
+		risk:        capabilityRiskLow,
+	},
+	"accelerate_search": {
+		description: "Accelerate reports and data models",
+		risk:        capabilityRiskLow,
+	},
+	"change_own_password": {
+		description: "Change the user's own password",
+		risk:        capabilityRiskLow,
+	},
+	"get_metadata": {
+		description: "Run the metadata search command",
+		risk:        capabilityRiskLow,
+	},
+	"list_users": {
+		description: "List users",
+		risk:        capabilityRiskLow,
+	},
+	"list_roles": {
+		description: "List roles",
+		risk:        capabilityRiskLow,
+	},
+	"list_inputs": {
+		description: "List data inputs",
+		risk:        capabilityRiskLow,
+	},
+	"export_results_is_visible": {
+		description: "Show the export button for search results",
+		risk:        capabilityRiskLow,
+	},
+}
+
+// describeCapability returns the description and risk tier of a capability.
+func describeCapability(capability string) capabilityInfo {
+	info, ok := capabilityCatalog[capability]
+	if !ok {
+		return capabilityInfo{
+			description: capability + " Splunk capability",
+			risk:        capabilityRiskUnknown,
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,257 @@
+package connector
+
+import (
+	"fmt"
+	"net/http"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+// clientRegistry holds one Splunk client per configured deployment, and one Admin Config Service
+// client per Splunk Cloud stack when the service is enabled. It is built once in New and only read afterwards,
+// apart from the listings of the current sync kept in cache.
+type clientRegistry struct {
+	deployments  []string
+	displayNames map[string]string
+	clients      map[string]*splunk.Client
+	acsClients   map[string]*acs.Client
+	cache        *syncCache
+
+	// writers send the writes of search head cluster members to their captain, and roles
+	// hold the roles of discovered nodes.
+	writers     map[string]*splunk.Client
+	writerNames map[string]string
+	roles       map[string][]string
+}
+
+// deploymentTarget is everything needed to reach one deployment.
+type deploymentTarget struct {
+	name        string
+	displayName string
+	baseURL     string
+	cloud       bool
+	auth        splunk.Authenticator
+	httpClient  *http.Client
+
+	// roles are set on nodes found by discovery, and writerBaseURL on the ones whose
+	// writes go to the node named writerName instead.
+	roles         []string
+	writerName    string
+	writerBaseURL string
+}
+
+// deploymentTargets returns targets sharing the same HTTP client, credentials and platform,
+// one for each deployment given by name, address or full URL.
+func deploymentTargets(
+	httpClient *http.Client,
+	auth splunk.Authenticator,
+	cloud bool,
+	deployments []string,
+) ([]deploymentTarget, error) {
+	// If no deployments are specified, the localhost deployment is used.
+	if len(deployments) == 0 {
+		deployments = []string{splunk.Localhost}
+	}
+
+	targets := make([]deploymentTarget, 0, len(deployments))
+	for _, deployment := range deployments {
+		// Deployments given as full URLs are named after their host and port.
+		name, baseURL, err := splunk.ParseDeployment(deployment)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: %w", err)
+		}
+
+		targets = append(targets, deploymentTarget{
+			name:       name,
+			baseURL:    baseURL,
+			cloud:      cloud,
+			auth:       auth,
+			httpClient: httpClient,
+		})
+	}
+
+	return targets, nil
+}
+
+func newClientRegistry(targets []deploymentTarget, opts ...splunk.ClientOption) (*clientRegistry, error) {
+	names := make([]string, 0, len(targets))
+	displayNames := make(map[string]string, len(targets))
+	clients := make(map[string]*splunk.Client, len(targets))
+	writers := make(map[string]*splunk.Client)
+	writerNames := make(map[string]string)
+	roles := make(map[string][]string)
+	for _, target := range targets {
+		if _, ok := clients[target.name]; ok {
+			return nil, fmt.Errorf("splunk-connector: deployment %s is configured twice", target.name)
+		}
+
+		clientOpts := opts
+		if target.baseURL != "" {
+			clientOpts = append(append([]splunk.ClientOption{}, opts...), splunk.WithBaseURL(target.baseURL))
+		}
+
+		names = append(names, target.name)
+		clients[target.name] = splunk.NewClient(target.httpClient, target.auth, target.cloud, target.name, clientOpts...)
+
+		if target.displayName != "" {
+			displayNames[target.name] = target.displayName
+		}
+
+		if len(target.roles) > 0 {
+			roles[target.name] = target.roles
+		}
+
+		// The writer is bound to the same deployment, so resource IDs of the member resolve against it.
+		if target.writerBaseURL != "" {
+			writerOpts := append(append([]splunk.ClientOption{}, opts...), splunk.WithBaseURL(target.writerBaseURL))
+
+			writers[target.name] = splunk.NewClient(target.httpClient, target.auth, target.cloud, target.name, writerOpts...)
+			writerNames[target.name] = target.writerName
+		}
+	}
+
+	return &clientRegistry{
+		deployments:  names,
+		displayNames: displayNames,
+		clients:      clients,
+		cache:        newSyncCache(),
+		writers:      writers,
+		writerNames:  writerNames,
+		roles:        roles,
+	}, nil
+}
+
+// displayName returns the name the deployment is shown with, defaulting to its title-cased name.
+func (r *clientRegistry) displayName(deployment string) string {
+	if displayName, ok := r.displayNames[deployment]; ok {
+		return displayName
+	}
+
+	return titleCase(deployment)
+}
+
+// hasOnPremise reports whether any of the deployments is an on-premise Splunk deployment.
+func (r *clientRegistry) hasOnPremise() bool {
+	for _, client := range r.clients {
+		if !client.IsCloudPlatform() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// onlyDeployment returns the deployment objects are created on when none is picked, which is only
+// possible when a single deployment is synced.
+func (r *clientRegistry) onlyDeployment() (string, error) {
+	if len(r.deployments) != 1 {
+		return "", fmt.Errorf("splunk-connector: a deployment has to be picked when syncing %d deployments", len(r.deployments))
+	}
+
+	return r.deployments[0], nil
+}
+
+// client returns the client bound to the given deployment.
+func (r *clientRegistry) client(deployment string) (*splunk.Client, error) {
+	client, ok := r.clients[deployment]
+	if !ok {
+		return nil, fmt.Errorf("splunk-connector: unknown deployment %s", deployment)
+	}
+
+	return client, nil
+}
+
+// enableACS creates an Admin Config Service client for each deployment that is a Splunk Cloud stack,
+// and reports whether there was any.
+func (r *clientRegistry) enableACS(httpClient *http.Client, baseURL string, token splunk.Secret) bool {
+	r.acsClients = make(map[string]*acs.Client, len(r.deployments))
+	for _, deployment := range r.deployments {
+		if r.clients[deployment].IsCloudPlatform() {
+			r.acsClients[deployment] = acs.NewClient(httpClient, baseURL, deployment, token)
+		}
+	}
+
+	return len(r.acsClients) > 0
+}
+
+// acsClient returns the Admin Config Service client of the given deployment, or nil when the service isn't enabled.
+func (r *clientRegistry) acsClient(deployment string) *acs.Client {
+	return r.acsClients[deployment]
+}
+
+// writer returns the client writes to the given deployment go through, which is the client of
+// the deployment itself unless it is a member of a search head cluster.
+func (r *clientRegistry) writer(deployment string) (*splunk.Client, error) {
+	if writer, ok := r.writers[deployment]; ok {
+		return writer, nil
+	}
+
+	return r.client(deployment)
+}
+
+// writerFor returns the client writes to the deployment the given resource belongs to go through.
+// Reads needed by a write should use it too, so they don't miss changes still being replicated.
+func (r *clientRegistry) writerFor(resource *v2.Resource) (*splunk.Client, error) {
+	deployment, err := deploymentOf(resource)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: %w", err)
+	}
+
+	return r.writer(deployment)
+}
+
+// clientFor returns the client of the deployment the given resource belongs to.
+func (r *clientRegistry) clientFor(resource *v2.Resource) (*splunk.Client, error) {
+	deployment, err := deploymentOf(resource)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: %w", err)
+	}
+
+	return r.client(deployment)
+}
+
+// deploymentOf returns the deployment resource ID the resource is scoped to.
+func deploymentOf(resource *v2.Resource) (string, error) {
+	if resource == nil || resource.Id == nil {
+		return "", fmt.Errorf("missing resource id")
+	}
+
+	if resource.Id.ResourceType == resourceTypeDeployment.Id {
+		return resource.Id.Resource, nil
+	}
+
+	parent := resource.ParentResourceId
+	if parent != nil && parent.ResourceType == resourceTypeDeployment.Id {
+		return parent.Resource, nil
+	}
+
+	deployment, _, err := parseNamespacedID(resource.Id.Resource)
+	if err != nil {
+		return "", err
+	}
+
+	return deployment, nil
+}
+
+// objectName returns the Splunk object name of a namespaced resource,
+// making sure it lives on the expected deployment.
+func objectName(resourceID *v2.ResourceId, deployment string) (string, error) {
+	resourceDeployment, name, err := parseNamespacedID(resourceID.Resource)
+	if err != nil {
+		return "", fmt.Errorf("splunk-connector: %w", err)
+	}
+
+	if resourceDeployment != deployment {
+		return "", fmt.Errorf(
+			"splunk-connector: %s %s belongs to deployment %s, not %s",
+			resourceID.ResourceType,
+			name,
+			resourceDeployment,
+			deployment,
+		)
+	}
+
+	return name, nil
+}
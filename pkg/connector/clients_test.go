@@ -0,0 +1,102 @@
+package connector
+
+import (
+	"testing"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+)
+
+func TestDeploymentOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource *v2.Resource
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "deployment",
+			resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeDeployment.Id, Resource: "splunk.corp"}},
+			want:     "splunk.corp",
+		},
+		{
+			name: "child of deployment",
+			resource: &v2.Resource{
+				Id:               &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: "other:admin"},
+				ParentResourceId: &v2.ResourceId{ResourceType: resourceTypeDeployment.Id, Resource: "splunk.corp"},
+			},
+			want: "splunk.corp",
+		},
+		{
+			name:     "namespaced id",
+			resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: "splunk.corp:admin"}},
+			want:     "splunk.corp",
+		},
+		{
+			name:     "deployment with port",
+			resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: "splunk.corp:8443:admin"}},
+			want:     "splunk.corp:8443",
+		},
+		{
+			name:     "missing deployment",
+			resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: "admin"}},
+			wantErr:  true,
+		},
+		{
+			name:     "missing name",
+			resource: &v2.Resource{Id: &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: "splunk.corp:"}},
+			wantErr:  true,
+		},
+		{
+			name:     "missing id",
+			resource: &v2.Resource{},
+			wantErr:  true,
+		},
+		{
+			name:    "missing resource",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := deploymentOf(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("deploymentOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("deploymentOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	tests := []struct {
+		name       string
+		resource   string
+		deployment string
+		want       string
+		wantErr    bool
+	}{
+		{name: "same deployment", resource: "splunk.corp:admin", deployment: "splunk.corp", want: "admin"},
+		{name: "deployment with port", resource: "splunk.corp:8443:admin", deployment: "splunk.corp:8443", want: "admin"},
+		{name: "other deployment", resource: "other.corp:admin", deployment: "splunk.corp", wantErr: true},
+		{name: "not namespaced", resource: "admin", deployment: "splunk.corp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceID := &v2.ResourceId{ResourceType: resourceTypeRole.Id, Resource: tt.resource}
+
+			got, err := objectName(resourceID, tt.deployment)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("objectName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("objectName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
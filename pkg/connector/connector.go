@@ -2,16 +2,19 @@ package connector
 
 import (
 	"context"
-	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/uhttp"
+	"github.com/conductorone/baton-splunk/pkg/offline"
 	"github.com/conductorone/baton-splunk/pkg/splunk"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"go.uber.org/zap"
 )
 
 var (
@@ -25,7 +28,6 @@ var (
 		Traits: []v2.ResourceType_Trait{
 			v2.ResourceType_TRAIT_USER,
 		},
-		Annotations: annotationsForUserResourceType(),
 	}
 	resourceTypeRole = &v2.ResourceType{
 		Id:          "role",
@@ -34,30 +36,107 @@ var (
 			v2.ResourceType_TRAIT_GROUP,
 		},
 	}
+	resourceTypeSAMLGroup = &v2.ResourceType{
+		Id:          "saml_group",
+		DisplayName: "SAML Group",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_GROUP,
+		},
+	}
+	resourceTypeLDAPStrategy = &v2.ResourceType{
+		Id:          "ldap_strategy",
+		DisplayName: "LDAP Strategy",
+		Annotations: annotationsForSkippedResourceType(),
+	}
+	resourceTypeLDAPGroup = &v2.ResourceType{
+		Id:          "ldap_group",
+		DisplayName: "LDAP Group",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_GROUP,
+		},
+	}
+	resourceTypeCapability = &v2.ResourceType{
+		Id:          "capability",
+		DisplayName: "Capability",
+	}
 	resourceTypeApplication = &v2.ResourceType{
 		Id:          "application",
 		DisplayName: "Application",
 	}
+	resourceTypeIndex = &v2.ResourceType{
+		Id:          "index",
+		DisplayName: "Index",
+	}
+	resourceTypeToken = &v2.ResourceType{
+		Id:          "token",
+		DisplayName: "Token",
+		Traits: []v2.ResourceType_Trait{
+			v2.ResourceType_TRAIT_SECRET,
+		},
+	}
+	resourceTypeSavedSearch = &v2.ResourceType{
+		Id:          "saved_search",
+		DisplayName: "Saved Search",
+	}
+	resourceTypeDashboard = &v2.ResourceType{
+		Id:          "dashboard",
+		DisplayName: "Dashboard",
+	}
+	resourceTypeHECToken = &v2.ResourceType{
+		Id:          "hec_token",
+		DisplayName: "HEC Token",
+		Annotations: annotationsForSkippedResourceType(),
+	}
+	resourceTypeIPAllowlist = &v2.ResourceType{
+		Id:          "ip_allowlist",
+		DisplayName: "IP Allowlist",
+		Annotations: annotationsForSkippedResourceType(),
+	}
 )
 
 type Splunk struct {
-	client  *splunk.Client
+	clients *clientRegistry
 	verbose bool
+	acs     bool
 
-	cloud       bool
-	deployments []string
+	deprovision deprovisionConfig
 }
 
 func (sp *Splunk) ResourceSyncers(ctx context.Context) []connectorbuilder.ResourceSyncer {
 	builders := []connectorbuilder.ResourceSyncer{
-		deploymentBuilder(sp.client, sp.verbose, sp.deployments),
-		userBuilder(sp.client),
-		roleBuilder(sp.client),
+		deploymentBuilder(sp.clients, sp.verbose),
+		userBuilder(sp.clients, sp.deprovision),
+		roleBuilder(sp.clients),
+		samlGroupBuilder(sp.clients),
+		ldapStrategyBuilder(sp.clients),
+		ldapGroupBuilder(sp.clients),
+		indexBuilder(sp.clients),
+		tokenBuilder(sp.clients),
+		capabilityBuilder(sp.clients),
 	}
 
-	// Applications are only supported for on-premise Splunk deployments.
-	if !sp.client.Cloud {
-		builders = append(builders, applicationBuilder(sp.client, sp.verbose))
+	// Applications, and the knowledge objects scoped under them, are only supported for on-premise Splunk deployments.
+	// On Splunk Cloud, the Admin Config Service lists the installed apps and the stack settings
+	// that the management API doesn't expose.
+	onPremise := sp.clients.hasOnPremise()
+	if onPremise || sp.acs {
+		builders = append(builders, applicationBuilder(sp.clients, sp.verbose))
+	}
+
+	if onPremise {
+		builders = append(
+			builders,
+			savedSearchBuilder(sp.clients),
+			dashboardBuilder(sp.clients),
+		)
+	}
+
+	if sp.acs {
+		builders = append(
+			builders,
+			hecTokenBuilder(sp.clients),
+			ipAllowlistBuilder(sp.clients),
+		)
 	}
 
 	return builders
@@ -72,33 +151,37 @@ func (sp *Splunk) Metadata(ctx context.Context) (*v2.ConnectorMetadata, error) {
 }
 
 // Validate hits the Splunk API to validate that the configured credentials are valid and compatible.
+// Every deployment is checked, and the result of each is logged, so one failing deployment doesn't hide another.
 func (sp *Splunk) Validate(ctx context.Context) (annotations.Annotations, error) {
-	if len(sp.deployments) == 0 {
-		// no need to point to a deployment since client is initialized with default localhost deployment
-		_, _, err := sp.client.GetUsers(ctx, splunk.PaginationVars{Limit: 1})
+	l := ctxzap.Extract(ctx)
+
+	var failures []string
+	for _, deployment := range sp.clients.deployments {
+		client, err := sp.clients.client(deployment)
 		if err != nil {
-			return nil, status.Error(
-				codes.Unauthenticated,
-				"Provided Password or Access Token is invalid for the given localhost deployment",
-			)
+			return nil, err
 		}
-	}
-
-	for _, deployment := range sp.deployments {
-		sp.client.PointToDeployment(deployment)
 
 		// should be able to list users
-		_, _, err := sp.client.GetUsers(ctx, splunk.PaginationVars{Limit: 1})
+		_, _, err = client.GetUsers(ctx, splunk.PaginationVars{Limit: 1})
 		if err != nil {
-			return nil, status.Errorf(
-				codes.Unauthenticated,
-				"Provided Password or Access Token is invalid for the given deployment %s",
-				deployment,
-			)
+			l.Error("splunk-connector: deployment failed validation", zap.String("deployment", deployment), zap.Error(err))
+			failures = append(failures, fmt.Sprintf("%s: %s", deployment, err))
+
+			continue
 		}
+
+		l.Info("splunk-connector: deployment validated", zap.String("deployment", deployment))
 	}
 
-	sp.client.ResetPointer()
+	if len(failures) > 0 {
+		return nil, fmt.Errorf(
+			"splunk-connector: failed to validate credentials for %d of %d deployments: %s",
+			len(failures),
+			len(sp.clients.deployments),
+			strings.Join(failures, "; "),
+		)
+	}
 
 	return nil, nil
 }
@@ -107,36 +190,191 @@ type CLIConfig struct {
 	Unsafe  bool
 	Verbose bool
 	Cloud   bool
+
+	// CABundlePath adds the PEM certificates of an internal CA to the trusted roots. ClientCertPath and
+	// ClientKeyPath point to the PEM certificate and key presented to deployments requiring mutual TLS.
+	CABundlePath   string
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// LockoutRole is the role users are moved to when their login is revoked.
+	LockoutRole string
+
+	// KnowledgeObjectOwner takes over the knowledge objects of users before they are locked or deleted.
+	KnowledgeObjectOwner string
+
+	// DryRun lists the knowledge objects that would be reassigned instead of locking or deleting users.
+	DryRun bool
+
+	// MaxRetries and RetryBackoff control retries of transient failures, with the backoff doubling on each retry.
+	MaxRetries   int
+	RetryBackoff time.Duration
+
+	// MaxConcurrentRequests caps the requests in flight to each deployment. 0 means unlimited.
+	MaxConcurrentRequests int
+
+	// ACS lists apps, indexes, tokens and stack settings of Splunk Cloud stacks through the Admin Config
+	// Service at ACSURL, authenticating with ACSToken.
+	ACS      bool
+	ACSURL   string
+	ACSToken splunk.Secret
+
+	// Deployments, when set, replace the deployments and credentials passed to New with per-deployment settings.
+	Deployments []DeploymentConfig
+
+	// Discover replaces the deployments with the members of their search head cluster and the peers
+	// and manager of their indexer cluster, sending writes to search head cluster members to the captain.
+	Discover bool
+
+	// OfflinePath points to a Splunk etc directory or an archive of it to sync from instead of the REST API.
+	OfflinePath string
+}
+
+// DeploymentConfig holds the settings of a single deployment, for deployments that don't share
+// their address, credentials or certificates.
+type DeploymentConfig struct {
+	// Name identifies the deployment, and prefixes the IDs of its resources.
+	Name        string
+	DisplayName string
+
+	// URL is the base URL of the management API, derived from Name and Cloud when empty.
+	URL   string
+	Cloud bool
+	Auth  splunk.Authenticator
+
+	Unsafe         bool
+	CABundlePath   string
+	ClientCertPath string
+	ClientKeyPath  string
 }
 
 // New returns the Splunk connector.
-func New(ctx context.Context, auth string, config CLIConfig, deployments []string) (*Splunk, error) {
-	options := []uhttp.Option{
-		uhttp.WithLogger(true, ctxzap.Extract(ctx)),
+func New(ctx context.Context, auth splunk.Authenticator, config CLIConfig, deployments []string) (*Splunk, error) {
+	if config.OfflinePath != "" {
+		snapshot, err := offline.Load(config.OfflinePath)
+		if err != nil {
+			return nil, err
+		}
+
+		// Requests are answered from the configuration files, so the same resource syncers work offline.
+		httpClient := &http.Client{Transport: offline.NewTransport(snapshot)}
+
+		targets, err := deploymentTargets(httpClient, splunk.StaticAuth(""), false, deployments)
+		if err != nil {
+			return nil, err
+		}
+
+		registry, err := newClientRegistry(targets)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Splunk{
+			clients: registry,
+			verbose: config.Verbose,
+
+			deprovision: deprovisionConfig{
+				lockoutRole:          config.LockoutRole,
+				knowledgeObjectOwner: config.KnowledgeObjectOwner,
+				dryRun:               config.DryRun,
+			},
+		}, nil
 	}
 
-	// Skip TLS verification if flag `unsafe` is specified.
-	if config.Unsafe { // #nosec G402
-		options = append(
-			options,
-			uhttp.WithTLSClientConfig(
-				&tls.Config{InsecureSkipVerify: true}, // #nosec G402
-			),
-		)
+	httpClient, err := newHTTPClient(ctx, config.Unsafe, config.CABundlePath, config.ClientCertPath, config.ClientKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []deploymentTarget
+	if len(config.Deployments) > 0 {
+		targets, err = configuredTargets(ctx, config.Deployments)
+	} else {
+		targets, err = deploymentTargets(httpClient, auth, config.Cloud, deployments)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts := []splunk.ClientOption{
+		splunk.WithRetries(config.MaxRetries, config.RetryBackoff),
+		splunk.WithMaxConcurrentRequests(config.MaxConcurrentRequests),
+	}
+
+	if config.Discover {
+		targets, err = discoverTopology(ctx, targets, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	httpClient, err := uhttp.NewClient(
-		ctx,
-		options...,
-	)
+	registry, err := newClientRegistry(targets, clientOpts...)
 	if err != nil {
 		return nil, err
 	}
 
+	// The Admin Config Service is only enabled for the Splunk Cloud stacks among the deployments.
+	acsEnabled := false
+	if config.ACS {
+		acsEnabled = registry.enableACS(httpClient, config.ACSURL, config.ACSToken)
+	}
+
 	return &Splunk{
-		client:      splunk.NewClient(httpClient, auth, config.Cloud),
-		verbose:     config.Verbose,
-		cloud:       config.Cloud,
-		deployments: deployments,
+		clients: registry,
+		verbose: config.Verbose,
+		acs:     acsEnabled,
+
+		deprovision: deprovisionConfig{
+			lockoutRole:          config.LockoutRole,
+			knowledgeObjectOwner: config.KnowledgeObjectOwner,
+			dryRun:               config.DryRun,
+		},
 	}, nil
 }
+
+// configuredTargets returns a target for each deployment configured on its own, each with its own HTTP client
+// since deployments may trust different CAs or present different client certificates.
+func configuredTargets(ctx context.Context, deployments []DeploymentConfig) ([]deploymentTarget, error) {
+	targets := make([]deploymentTarget, 0, len(deployments))
+	for _, deployment := range deployments {
+		httpClient, err := newHTTPClient(
+			ctx,
+			deployment.Unsafe,
+			deployment.CABundlePath,
+			deployment.ClientCertPath,
+			deployment.ClientKeyPath,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: deployment %s: %w", deployment.Name, err)
+		}
+
+		targets = append(targets, deploymentTarget{
+			name:        deployment.Name,
+			displayName: deployment.DisplayName,
+			baseURL:     strings.TrimSuffix(deployment.URL, "/"),
+			cloud:       deployment.Cloud,
+			auth:        deployment.Auth,
+			httpClient:  httpClient,
+		})
+	}
+
+	return targets, nil
+}
+
+// newHTTPClient returns an HTTP client logging its requests, with the given TLS settings.
+func newHTTPClient(ctx context.Context, unsafe bool, caBundlePath, clientCertPath, clientKeyPath string) (*http.Client, error) {
+	options := []uhttp.Option{
+		uhttp.WithLogger(true, ctxzap.Extract(ctx)),
+	}
+
+	clientTLSConfig, err := tlsConfig(unsafe, caBundlePath, clientCertPath, clientKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientTLSConfig != nil {
+		options = append(options, uhttp.WithTLSClientConfig(clientTLSConfig))
+	}
+
+	return uhttp.NewClient(ctx, options...)
+}
@@ -8,17 +8,17 @@ import (
 	"github.com/conductorone/baton-sdk/pkg/annotations"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
 	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
-	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-splunk/pkg/splunk"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type deploymentResourceType struct {
 	resourceType *v2.ResourceType
-	client       *splunk.Client
-	deployments  []string
+	clients      *clientRegistry
 	verbose      bool
 }
 
@@ -27,18 +27,46 @@ func (d *deploymentResourceType) ResourceType(_ context.Context) *v2.ResourceTyp
 }
 
 // deploymentResource creates a new connector resource for a Splunk Deployment under which all other resources are scoped.
-func deploymentResource(ctx context.Context, deployment string) (*v2.Resource, error) {
-	displayName := titleCase(deployment)
+// Nodes found by topology discovery also carry their roles, and the captain their writes go to.
+func deploymentResource(
+	ctx context.Context,
+	deployment string,
+	displayName string,
+	childTypes []proto.Message,
+	roles []string,
+	writerName string,
+) (*v2.Resource, error) {
+	resourceOptions := []rs.ResourceOption{
+		rs.WithAnnotation(childTypes...),
+	}
+
+	if len(roles) > 0 {
+		description := describeNodeRoles(roles)
+		if writerName != "" {
+			description += fmt.Sprintf(", writing through captain %s", writerName)
+		}
+
+		nodeRoles := make([]interface{}, 0, len(roles))
+		for _, role := range roles {
+			nodeRoles = append(nodeRoles, role)
+		}
+
+		metadata, err := structpb.NewStruct(map[string]interface{}{
+			"roles":   nodeRoles,
+			"captain": writerName,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		resourceOptions = append(resourceOptions, rs.WithDescription(description), rs.WithAnnotation(metadata))
+	}
 
 	resource, err := rs.NewResource(
 		displayName,
 		resourceTypeDeployment,
 		deployment,
-		rs.WithAnnotation(
-			&v2.ChildResourceType{ResourceTypeId: resourceTypeRole.Id},
-			&v2.ChildResourceType{ResourceTypeId: resourceTypeUser.Id},
-			&v2.ChildResourceType{ResourceTypeId: resourceTypeApplication.Id},
-		),
+		resourceOptions...,
 	)
 	if err != nil {
 		return nil, err
@@ -47,31 +75,67 @@ func deploymentResource(ctx context.Context, deployment string) (*v2.Resource, e
 	return resource, nil
 }
 
-func (d *deploymentResourceType) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	rv := make([]*v2.Resource, 0)
+// deploymentResourceID returns the ID of the deployment resource other resources are scoped under.
+func deploymentResourceID(deployment string) *v2.ResourceId {
+	return &v2.ResourceId{
+		ResourceType: resourceTypeDeployment.Id,
+		Resource:     deployment,
+	}
+}
 
-	// If no deployments are specified, return the localhost deployment
-	if len(d.deployments) == 0 {
-		dr, err := deploymentResource(ctx, splunk.Localhost)
+// List lists the deployments, which starts every sync, so it also drops the listings cached by the previous one.
+func (d *deploymentResourceType) List(ctx context.Context, _ *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	d.clients.cache.reset()
+
+	rv := make([]*v2.Resource, 0, len(d.clients.deployments))
+
+	for _, deployment := range d.clients.deployments {
+		dr, err := deploymentResource(
+			ctx,
+			deployment,
+			d.clients.displayName(deployment),
+			d.childTypes(deployment),
+			d.clients.roles[deployment],
+			d.clients.writerNames[deployment],
+		)
 		if err != nil {
 			return nil, "", nil, err
 		}
 
 		rv = append(rv, dr)
+	}
 
-		return rv, "", nil, nil
+	return rv, "", nil, nil
+}
+
+// childTypes returns the resource types listed under a deployment. The SDK fails the sync on child types without a
+// syncer, so applications are only declared where they can be listed, and the stack settings only where the Admin
+// Config Service is enabled.
+func (d *deploymentResourceType) childTypes(deployment string) []proto.Message {
+	rv := []proto.Message{
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeRole.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeUser.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeSAMLGroup.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeLDAPStrategy.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeIndex.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeToken.Id},
+		&v2.ChildResourceType{ResourceTypeId: resourceTypeCapability.Id},
 	}
 
-	for _, deployment := range d.deployments {
-		dr, err := deploymentResource(ctx, deployment)
-		if err != nil {
-			return nil, "", nil, err
-		}
+	acsEnabled := d.clients.acsClient(deployment) != nil
+	if acsEnabled || !d.clients.clients[deployment].IsCloudPlatform() {
+		rv = append(rv, &v2.ChildResourceType{ResourceTypeId: resourceTypeApplication.Id})
+	}
 
-		rv = append(rv, dr)
+	if acsEnabled {
+		rv = append(
+			rv,
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeHECToken.Id},
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeIPAllowlist.Id},
+		)
 	}
 
-	return rv, "", nil, nil
+	return rv
 }
 
 func (d *deploymentResourceType) Entitlements(ctx context.Context, resource *v2.Resource, pt *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
@@ -84,8 +148,12 @@ func (d *deploymentResourceType) Entitlements(ctx context.Context, resource *v2.
 		return nil, "", nil, err
 	}
 
-	d.client.PointToDeployment(resource.Id.Resource)
-	capabilitiesEntry, nextPage, err := d.client.GetCapabilities(
+	client, err := d.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	capabilitiesEntry, nextPage, err := client.GetCapabilities(
 		ctx,
 		splunk.PaginationVars{
 			Limit: ResourcesPageSize,
@@ -117,55 +185,42 @@ func (d *deploymentResourceType) Entitlements(ctx context.Context, resource *v2.
 	return rv, pageToken, nil, nil
 }
 
-func (d *deploymentResourceType) Grants(ctx context.Context, resource *v2.Resource, pt *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+// Grants grants each role its own capabilities. The grants are expanded to the members of the role,
+// so the roles importing it and their users hold the capabilities too.
+func (d *deploymentResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
 	// Grant only the deployment capabilities if verbose mode is enabled.
 	if !d.verbose {
 		return nil, "", nil, nil
 	}
 
-	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeUser.Id})
+	client, err := d.clients.clientFor(resource)
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	d.client.PointToDeployment(resource.Id.Resource)
-	roles, nextPage, err := d.client.GetRoles(
-		ctx,
-		splunk.PaginationVars{
-			Limit: ResourcesPageSize,
-			Page:  bag.PageToken(),
-		},
-	)
+	roles, err := d.clients.cache.allRoles(ctx, client)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
 	}
 
-	pageToken, err := bag.NextToken(nextPage)
-	if err != nil {
-		return nil, "", nil, err
-	}
-
 	var rv []*v2.Grant
 	for _, role := range roles {
 		roleCopy := role
 
-		rr, err := roleResource(ctx, &roleCopy, resource.ParentResourceId)
+		rr, err := roleResource(ctx, &roleCopy, resource.Id)
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build role resource: %w", err)
 		}
 
 		for _, capability := range role.Content.Capabilities {
-			rv = append(rv, grant.NewGrant(
-				resource,
-				capability,
-				rr.Id,
-			))
+			rv = append(rv, roleMemberGrant(resource, capability, rr))
 		}
 	}
 
-	return rv, pageToken, nil, nil
+	return rv, "", nil, nil
 }
 
+// Grant adds the capability of the entitlement to a role, like granting the capability resource does.
 func (d *deploymentResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
@@ -179,31 +234,20 @@ func (d *deploymentResourceType) Grant(ctx context.Context, principal *v2.Resour
 		return nil, fmt.Errorf("splunk-connector: only roles can be granted capability membership")
 	}
 
-	targetCapabilityId := entitlement.Slug
-
-	// get existing capabilities under role
-	role, err := d.client.GetRole(ctx, principal.Id.Resource)
+	client, err := d.clients.writerFor(entitlement.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("splunk-connector: failed to find role: %w", err)
-	}
-
-	// check if capability is already granted
-	if isResourcePresent(role.Content.Capabilities, targetCapabilityId) {
-		return nil, fmt.Errorf("splunk-connector: capability %s already granted to role", targetCapabilityId)
+		return nil, err
 	}
 
-	// merge new capability into existing capabilities
-	role.Content.Capabilities = append(role.Content.Capabilities, targetCapabilityId)
-
-	// grant capability membership
-	err = d.client.UpdateRoleCapabilities(ctx, principal.Id.Resource, role.Content.Capabilities)
+	roleName, err := objectName(principal.Id, client.Deployment())
 	if err != nil {
-		return nil, fmt.Errorf("splunk-connector: failed to grant capability membership: %w", err)
+		return nil, err
 	}
 
-	return nil, nil
+	return nil, grantRoleCapability(ctx, client, roleName, entitlement.Slug)
 }
 
+// Revoke removes the capability of the entitlement from a role, like revoking the capability resource does.
 func (d *deploymentResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
@@ -220,36 +264,23 @@ func (d *deploymentResourceType) Revoke(ctx context.Context, grant *v2.Grant) (a
 		return nil, fmt.Errorf("splunk-connector: only roles can have capability membership revoked")
 	}
 
-	targetCapabilityId := entitlement.Slug
-
-	// get existing capabilities under role
-	role, err := d.client.GetRole(ctx, principal.Id.Resource)
+	client, err := d.clients.writerFor(entitlement.Resource)
 	if err != nil {
-		return nil, fmt.Errorf("splunk-connector: failed to find role: %w", err)
-	}
-
-	// check if capability is present in role's capabilities
-	if !isResourcePresent(role.Content.Capabilities, targetCapabilityId) {
-		return nil, fmt.Errorf("splunk-connector: capability %s not present in role's capabilities", targetCapabilityId)
+		return nil, err
 	}
 
-	// remove new capability from existing capabilities
-	role.Content.Capabilities = removeResource(role.Content.Capabilities, targetCapabilityId)
-
-	// revoke capability membership
-	err = d.client.UpdateRoleCapabilities(ctx, principal.Id.Resource, role.Content.Capabilities)
+	roleName, err := objectName(principal.Id, client.Deployment())
 	if err != nil {
-		return nil, fmt.Errorf("splunk-connector: failed to revoke capability membership: %w", err)
+		return nil, err
 	}
 
-	return nil, nil
+	return nil, revokeRoleCapability(ctx, client, roleName, entitlement.Slug)
 }
 
-func deploymentBuilder(client *splunk.Client, verbose bool, deployments []string) *deploymentResourceType {
+func deploymentBuilder(clients *clientRegistry, verbose bool) *deploymentResourceType {
 	return &deploymentResourceType{
 		resourceType: resourceTypeDeployment,
-		client:       client,
+		clients:      clients,
 		verbose:      verbose,
-		deployments:  deployments,
 	}
 }
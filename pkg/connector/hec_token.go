@@ -0,0 +1,117 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+type hecTokenResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (h *hecTokenResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return h.resourceType
+}
+
+// hecTokenResource creates a new connector resource for an HTTP Event Collector token of a Splunk Cloud stack.
+func hecTokenResource(ctx context.Context, hecToken *acs.HECToken, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	spec := hecToken.Spec
+
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: HEC token %s is missing its deployment", spec.Name)
+	}
+
+	allowedIndexes := "any index"
+	if len(spec.AllowedIndexes) > 0 {
+		allowedIndexes = strings.Join(spec.AllowedIndexes, ", ")
+	}
+
+	description := fmt.Sprintf("HTTP Event Collector token writing to %s by default, allowed to write to %s", spec.DefaultIndex, allowedIndexes)
+	if spec.Disabled {
+		description += " (disabled)"
+	}
+
+	resource, err := rs.NewResource(
+		spec.Name,
+		resourceTypeHECToken,
+		namespacedID(parentResourceID.Resource, spec.Name),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(description),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (h *hecTokenResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// HEC tokens are only listed under the stack they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	acsClient := h.clients.acsClient(parentID.Resource)
+	if acsClient == nil {
+		return nil, "", nil, nil
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeHECToken.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	hecTokens, nextPage, err := acsClient.GetHECTokens(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list HEC tokens of stack %s: %w", acsClient.Stack(), err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(hecTokens))
+	for _, hecToken := range hecTokens {
+		hecTokenCopy := hecToken
+
+		hr, err := hecTokenResource(ctx, &hecTokenCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, hr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (h *hecTokenResourceType) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (h *hecTokenResourceType) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func hecTokenBuilder(clients *clientRegistry) *hecTokenResourceType {
+	return &hecTokenResourceType{
+		resourceType: resourceTypeHECToken,
+		clients:      clients,
+	}
+}
@@ -1,7 +1,9 @@
 package connector
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
@@ -13,15 +15,27 @@ import (
 
 const ResourcesPageSize = 50
 
+// deploymentSeparator separates the deployment from the Splunk object name in resource IDs.
+// Splunk user, role and app names can't contain a colon, so the last one marks the boundary.
+const deploymentSeparator = ":"
+
+const (
+	generatedPasswordLength  = 24
+	minGeneratedPasswordLen  = 8
+	generatedPasswordCharset = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789!@#%^*-_=+"
+)
+
 func titleCase(s string) string {
 	titleCaser := cases.Title(language.English)
 
 	return titleCaser.String(s)
 }
 
-func annotationsForUserResourceType() annotations.Annotations {
+// annotationsForSkippedResourceType marks resource types that have no entitlements or grants of their own.
+func annotationsForSkippedResourceType() annotations.Annotations {
 	annos := annotations.Annotations{}
 	annos.Update(&v2.SkipEntitlementsAndGrants{})
+
 	return annos
 }
 
@@ -42,6 +56,27 @@ func parsePageToken(i string, resourceID *v2.ResourceId) (*pagination.Bag, error
 	return b, nil
 }
 
+// parseMultiPageToken works like parsePageToken, but queues a page state for each resource type,
+// so that principals of different types are listed one after the other.
+func parseMultiPageToken(i string, resourceTypeIDs ...string) (*pagination.Bag, error) {
+	b := &pagination.Bag{}
+	err := b.Unmarshal(i)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Current() == nil {
+		// push in reverse order so the first resource type is listed first
+		for j := len(resourceTypeIDs) - 1; j >= 0; j-- {
+			b.Push(pagination.PageState{
+				ResourceTypeID: resourceTypeIDs[j],
+			})
+		}
+	}
+
+	return b, nil
+}
+
 func containsRole(roles []string, role string) bool {
 	for _, r := range roles {
 		if r == "*" {
@@ -56,6 +91,22 @@ func containsRole(roles []string, role string) bool {
 	return false
 }
 
+// namespacedID scopes a Splunk object name to the deployment it was listed from,
+// so that objects with the same name on different deployments don't collide.
+func namespacedID(deployment, name string) string {
+	return deployment + deploymentSeparator + name
+}
+
+// parseNamespacedID splits a resource ID created by namespacedID into its deployment and object name.
+func parseNamespacedID(id string) (string, string, error) {
+	separatorIndex := strings.LastIndex(id, deploymentSeparator)
+	if separatorIndex <= 0 || separatorIndex == len(id)-1 {
+		return "", "", fmt.Errorf("failed to parse deployment from resource id: %s", id)
+	}
+
+	return id[:separatorIndex], id[separatorIndex+1:], nil
+}
+
 func removeLeadingUrl(url string) (string, error) {
 	slashIndex := strings.LastIndex(url, "/")
 
@@ -66,6 +117,40 @@ func removeLeadingUrl(url string) (string, error) {
 	return "", fmt.Errorf("failed to parse resource id: %s", url)
 }
 
+// generatePassword returns a random password of the given length that satisfies the default Splunk
+// password policy. A length of 0 picks the default length.
+func generatePassword(length int) (string, error) {
+	if length == 0 {
+		length = generatedPasswordLength
+	}
+
+	if length < minGeneratedPasswordLen {
+		return "", fmt.Errorf("passwords must be at least %d characters long", minGeneratedPasswordLen)
+	}
+
+	charsetSize := big.NewInt(int64(len(generatedPasswordCharset)))
+
+	for {
+		password := make([]byte, length)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, charsetSize)
+			if err != nil {
+				return "", err
+			}
+
+			password[i] = generatedPasswordCharset[n.Int64()]
+		}
+
+		// make sure every character class is represented
+		if strings.ContainsAny(string(password), "0123456789") &&
+			strings.ContainsAny(string(password), "abcdefghijklmnopqrstuvwxyz") &&
+			strings.ContainsAny(string(password), "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+			strings.ContainsAny(string(password), "!@#%^*-_=+") {
+			return string(password), nil
+		}
+	}
+}
+
 func removeResource(resources []string, targetResource string) []string {
 	var nr []string
 
@@ -0,0 +1,429 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const (
+	indexSearch        = "search"
+	indexDefaultSearch = "default_search"
+	indexDelete        = "delete"
+)
+
+// indexPermission ties an index entitlement to the role field that grants it.
+type indexPermission struct {
+	slug        string
+	field       string
+	displayName string
+	description string
+}
+
+var indexPermissions = []indexPermission{
+	{
+		slug:        indexSearch,
+		field:       splunk.SrchIndexesAllowedField,
+		displayName: "search",
+		description: "Roles allowed to search the index (srchIndexesAllowed)",
+	},
+	{
+		slug:        indexDefaultSearch,
+		field:       splunk.SrchIndexesDefaultField,
+		displayName: "default search",
+		description: "Roles searching the index by default (srchIndexesDefault)",
+	},
+	{
+		slug:        indexDelete,
+		field:       splunk.DeleteIndexesAllowedField,
+		displayName: "delete",
+		description: "Roles allowed to delete events from the index (deleteIndexesAllowed)",
+	},
+}
+
+type indexResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (i *indexResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return i.resourceType
+}
+
+// indexResource creates a new connector resource for a Splunk Index.
+func indexResource(ctx context.Context, index *splunk.Index, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: index %s is missing its deployment", index.Name)
+	}
+
+	description := fmt.Sprintf("Splunk %s index", index.Content.DataType)
+	if index.Content.Disabled {
+		description += " (disabled)"
+	}
+
+	resource, err := rs.NewResource(
+		index.Name,
+		resourceTypeIndex,
+		namespacedID(parentResourceID.Resource, index.Name),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(description),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (i *indexResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// Indexes are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := i.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeIndex.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Splunk Cloud stacks list their indexes through the Admin Config Service.
+	if acsClient := i.clients.acsClient(client.Deployment()); acsClient != nil {
+		return i.listACSIndexes(ctx, acsClient, parentID, bag)
+	}
+
+	indexes, nextPage, err := client.GetIndexes(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list indexes: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(indexes))
+	for _, index := range indexes {
+		indexCopy := index
+
+		ir, err := indexResource(ctx, &indexCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, ir)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (i *indexResourceType) listACSIndexes(
+	ctx context.Context,
+	acsClient *acs.Client,
+	parentID *v2.ResourceId,
+	bag *pagination.Bag,
+) ([]*v2.Resource, string, annotations.Annotations, error) {
+	acsIndexes, nextPage, err := acsClient.GetIndexes(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list indexes of stack %s: %w", acsClient.Stack(), err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(acsIndexes))
+	for _, acsIndex := range acsIndexes {
+		index := splunk.Index{Name: acsIndex.Name}
+		index.Content.DataType = acsIndex.DataType
+
+		ir, err := indexResource(ctx, &index, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, ir)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (i *indexResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	rv := make([]*v2.Entitlement, 0, len(indexPermissions))
+	for _, permission := range indexPermissions {
+		rv = append(rv, ent.NewPermissionEntitlement(
+			resource,
+			permission.slug,
+			ent.WithGrantableTo(resourceTypeRole),
+			ent.WithDisplayName(fmt.Sprintf("%s index %s", resource.DisplayName, permission.displayName)),
+			ent.WithDescription(permission.description),
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+// Grants matches the index against the index lists of every role, including wildcard patterns. The grants are
+// expanded to the members of each role, so the roles importing it and their users get the access too.
+func (i *indexResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	client, err := i.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	indexName, err := objectName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	roles, err := i.clients.cache.allRoles(ctx, client)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, role := range roles {
+		roleCopy := role
+
+		rr, err := roleResource(ctx, &roleCopy, deploymentResourceID(client.Deployment()))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build role resource: %w", err)
+		}
+
+		for _, permission := range indexPermissions {
+			pattern, ok := matchingIndexPattern(roleIndexes(&roleCopy, permission.field), indexName)
+			if !ok {
+				continue
+			}
+
+			var grantOptions []grant.GrantOption
+			if pattern != indexName {
+				grantOptions = append(grantOptions, grant.WithGrantMetadata(map[string]interface{}{
+					"matched_pattern": pattern,
+				}))
+			}
+
+			rv = append(rv, roleMemberGrant(resource, permission.slug, rr, grantOptions...))
+		}
+	}
+
+	return rv, "", nil, nil
+}
+
+func (i *indexResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != resourceTypeRole.Id {
+		l.Warn(
+			"splunk-connector: only roles can be granted index access",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: only roles can be granted index access")
+	}
+
+	permission, err := indexPermissionFor(entitlement.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := i.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	indexName, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	// get existing index lists under role
+	role, err := client.GetRole(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	indexes := roleIndexes(role, permission.field)
+
+	// check if index is already granted, either by name or by pattern
+	if pattern, ok := matchingIndexPattern(indexes, indexName); ok {
+		return nil, fmt.Errorf("splunk-connector: index %s already granted to role through %s", indexName, pattern)
+	}
+
+	// grant index access
+	err = client.UpdateRoleIndexes(ctx, roleName, permission.field, append(indexes, indexName))
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to grant index access: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (i *indexResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := grant.Entitlement
+	principal := grant.Principal
+
+	if principal.Id.ResourceType != resourceTypeRole.Id {
+		l.Warn(
+			"splunk-connector: only roles can have index access revoked",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: only roles can have index access revoked")
+	}
+
+	permission, err := indexPermissionFor(entitlement.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := i.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	indexName, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	// get existing index lists under role
+	role, err := client.GetRole(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	indexes := roleIndexes(role, permission.field)
+
+	// access granted through a wildcard can't be revoked for a single index
+	if !isResourcePresent(indexes, indexName) {
+		if pattern, ok := matchingIndexPattern(indexes, indexName); ok {
+			return nil, fmt.Errorf("splunk-connector: index %s is granted to role through pattern %s, revoke the pattern instead", indexName, pattern)
+		}
+
+		return nil, fmt.Errorf("splunk-connector: index %s not present in role's %s", indexName, permission.field)
+	}
+
+	// revoke index access
+	err = client.UpdateRoleIndexes(ctx, roleName, permission.field, removeResource(indexes, indexName))
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to revoke index access: %w", err)
+	}
+
+	return nil, nil
+}
+
+func indexPermissionFor(slug string) (*indexPermission, error) {
+	for _, permission := range indexPermissions {
+		if permission.slug == slug {
+			permissionCopy := permission
+			return &permissionCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("splunk-connector: unknown index entitlement %s", slug)
+}
+
+// roleIndexes returns the index list of a role that backs the given role field.
+func roleIndexes(role *splunk.Role, field string) []string {
+	switch field {
+	case splunk.SrchIndexesAllowedField:
+		return role.Content.SrchIndexesAllowed
+	case splunk.SrchIndexesDefaultField:
+		return role.Content.SrchIndexesDefault
+	case splunk.DeleteIndexesAllowedField:
+		return role.Content.DeleteIndexesAllowed
+	default:
+		return nil
+	}
+}
+
+// matchingIndexPattern returns the first entry of an index list that covers the index.
+func matchingIndexPattern(patterns []string, index string) (string, bool) {
+	for _, pattern := range patterns {
+		if matchesIndexPattern(pattern, index) {
+			return pattern, true
+		}
+	}
+
+	return "", false
+}
+
+// matchesIndexPattern matches an index against a Splunk index pattern, where `*` matches any sequence
+// of characters. As in Splunk, internal indexes (starting with `_`) are only matched by patterns
+// that start with `_` too, so `*` covers every non-internal index.
+func matchesIndexPattern(pattern string, index string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == index
+	}
+
+	if strings.HasPrefix(index, "_") && !strings.HasPrefix(pattern, "_") {
+		return false
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(index, parts[0]) {
+		return false
+	}
+
+	rest := index[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		position := strings.Index(rest, part)
+		if position < 0 {
+			return false
+		}
+
+		rest = rest[position+len(part):]
+	}
+
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
+func indexBuilder(clients *clientRegistry) *indexResourceType {
+	return &indexResourceType{
+		resourceType: resourceTypeIndex,
+		clients:      clients,
+	}
+}
@@ -0,0 +1,37 @@
+package connector
+
+import "testing"
+
+func TestMatchingIndexPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		index    string
+		want     string
+		wantOK   bool
+	}{
+		{name: "exact name", patterns: []string{"main"}, index: "main", want: "main", wantOK: true},
+		{name: "other name", patterns: []string{"main"}, index: "mainframe", wantOK: false},
+		{name: "wildcard covers non-internal index", patterns: []string{"*"}, index: "main", want: "*", wantOK: true},
+		{name: "wildcard skips internal index", patterns: []string{"*"}, index: "_internal", wantOK: false},
+		{name: "internal wildcard covers internal index", patterns: []string{"_*"}, index: "_audit", want: "_*", wantOK: true},
+		{name: "prefix", patterns: []string{"web_*"}, index: "web_access", want: "web_*", wantOK: true},
+		{name: "prefix mismatch", patterns: []string{"web_*"}, index: "app_access", wantOK: false},
+		{name: "suffix", patterns: []string{"*_prod"}, index: "web_prod", want: "*_prod", wantOK: true},
+		{name: "middle", patterns: []string{"web_*_prod"}, index: "web_eu_prod", want: "web_*_prod", wantOK: true},
+		{name: "middle mismatch", patterns: []string{"web_*_prod"}, index: "web_eu_dev", wantOK: false},
+		{name: "several wildcards", patterns: []string{"a*b*c"}, index: "axxbyyc", want: "a*b*c", wantOK: true},
+		{name: "parts must not overlap", patterns: []string{"ab*ba"}, index: "aba", wantOK: false},
+		{name: "first match wins", patterns: []string{"other", "web_*", "*"}, index: "web_access", want: "web_*", wantOK: true},
+		{name: "no patterns", patterns: nil, index: "main", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchingIndexPattern(tt.patterns, tt.index)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("matchingIndexPattern(%q, %q) = %q, %v, want %q, %v", tt.patterns, tt.index, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+)
+
+type ipAllowlistResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (i *ipAllowlistResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return i.resourceType
+}
+
+// ipAllowlistResource creates a new connector resource for the IP allowlist of one feature of a Splunk Cloud stack.
+func ipAllowlistResource(ctx context.Context, feature string, subnets []string, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: %s IP allowlist is missing its deployment", feature)
+	}
+
+	description := fmt.Sprintf("No subnets allowed to reach %s", feature)
+	if len(subnets) > 0 {
+		description = fmt.Sprintf("Subnets allowed to reach %s: %s", feature, strings.Join(subnets, ", "))
+	}
+
+	resource, err := rs.NewResource(
+		fmt.Sprintf("%s IP allowlist", feature),
+		resourceTypeIPAllowlist,
+		namespacedID(parentResourceID.Resource, feature),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(description),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// List returns the allowlist of every feature of the stack, all at once since there are only a few of them.
+func (i *ipAllowlistResourceType) List(ctx context.Context, parentID *v2.ResourceId, _ *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// IP allowlists are only listed under the stack they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	acsClient := i.clients.acsClient(parentID.Resource)
+	if acsClient == nil {
+		return nil, "", nil, nil
+	}
+
+	rv := make([]*v2.Resource, 0, len(acs.IPAllowlistFeatures))
+	for _, feature := range acs.IPAllowlistFeatures {
+		subnets, err := acsClient.GetIPAllowlist(ctx, feature)
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to get %s IP allowlist of stack %s: %w", feature, acsClient.Stack(), err)
+		}
+
+		ir, err := ipAllowlistResource(ctx, feature, subnets, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, ir)
+	}
+
+	return rv, "", nil, nil
+}
+
+func (i *ipAllowlistResourceType) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (i *ipAllowlistResourceType) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func ipAllowlistBuilder(clients *clientRegistry) *ipAllowlistResourceType {
+	return &ipAllowlistResourceType{
+		resourceType: resourceTypeIPAllowlist,
+		clients:      clients,
+	}
+}
@@ -0,0 +1,398 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+const knowledgeObjectOwner = "owner"
+
+// knowledgeObjectSeparator joins the sharing level, app and name of a knowledge object in its resource ID.
+const knowledgeObjectSeparator = "/"
+
+// knowledgeObjectKind describes how to fetch and present one kind of knowledge object.
+type knowledgeObjectKind struct {
+	resourceType *v2.ResourceType
+	list         func(c *splunk.Client, ctx context.Context, app string, vars splunk.PaginationVars) ([]splunk.KnowledgeObject, string, error)
+	listByOwner  func(c *splunk.Client, ctx context.Context, owner string, vars splunk.PaginationVars) ([]splunk.KnowledgeObject, string, error)
+	get          func(c *splunk.Client, ctx context.Context, app string, sharing string, name string) (*splunk.KnowledgeObject, error)
+	updateOwner  func(c *splunk.Client, ctx context.Context, object *splunk.KnowledgeObject, owner string) error
+	include      func(object *splunk.KnowledgeObject) bool
+	describe     func(object *splunk.KnowledgeObject) string
+}
+
+var savedSearchKind = &knowledgeObjectKind{
+	resourceType: resourceTypeSavedSearch,
+	list:         (*splunk.Client).GetSavedSearches,
+	listByOwner:  (*splunk.Client).GetSavedSearchesByOwner,
+	get:          (*splunk.Client).GetSavedSearch,
+	updateOwner:  (*splunk.Client).UpdateSavedSearchOwner,
+	include: func(_ *splunk.KnowledgeObject) bool {
+		return true
+	},
+	describe: func(object *splunk.KnowledgeObject) string {
+		kind := "Saved search"
+		if object.IsAlert() {
+			kind = "Alert"
+		}
+
+		details := []string{describeOwnership(object)}
+		if object.Content.IsScheduled {
+			details = append(details, fmt.Sprintf("scheduled %s", object.Content.CronSchedule))
+		}
+
+		if object.Content.Disabled {
+			details = append(details, "disabled")
+		}
+
+		return fmt.Sprintf("%s %s", kind, strings.Join(details, ", "))
+	},
+}
+
+var dashboardKind = &knowledgeObjectKind{
+	resourceType: resourceTypeDashboard,
+	list:         (*splunk.Client).GetViews,
+	listByOwner:  (*splunk.Client).GetViewsByOwner,
+	get:          (*splunk.Client).GetView,
+	updateOwner:  (*splunk.Client).UpdateViewOwner,
+	// views also include pages of apps that aren't dashboards
+	include: func(object *splunk.KnowledgeObject) bool {
+		return object.Content.IsDashboard
+	},
+	describe: func(object *splunk.KnowledgeObject) string {
+		return fmt.Sprintf("Dashboard %s", describeOwnership(object))
+	},
+}
+
+// knowledgeObjectKinds lists every kind of knowledge object the connector knows about.
+var knowledgeObjectKinds = []*knowledgeObjectKind{savedSearchKind, dashboardKind}
+
+type knowledgeObjectResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+	kind         *knowledgeObjectKind
+}
+
+func (k *knowledgeObjectResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return k.resourceType
+}
+
+// knowledgeObjectResource creates a new connector resource for a Splunk knowledge object, scoped under its app.
+func knowledgeObjectResource(ctx context.Context, kind *knowledgeObjectKind, object *splunk.KnowledgeObject, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: %s %s is missing its application", kind.resourceType.DisplayName, object.Name)
+	}
+
+	deployment, _, err := parseNamespacedID(parentResourceID.Resource)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: %w", err)
+	}
+
+	displayName := object.Content.Label
+	if displayName == "" {
+		displayName = object.Name
+	}
+
+	resource, err := rs.NewResource(
+		displayName,
+		kind.resourceType,
+		namespacedID(deployment, knowledgeObjectID(object.ACL.Sharing, object.ACL.App, object.Name)),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(kind.describe(object)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (k *knowledgeObjectResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// Knowledge objects are only listed under the application they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeApplication.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := k.clients.clientFor(&v2.Resource{Id: parentID})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Knowledge objects are only listed on on-premise deployments.
+	if client.IsCloudPlatform() {
+		return nil, "", nil, nil
+	}
+
+	applicationName, err := objectName(parentID, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: k.resourceType.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	objects, nextPage, err := k.kind.list(
+		client,
+		ctx,
+		applicationName,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list %s resources: %w", k.resourceType.Id, err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(objects))
+	for _, object := range objects {
+		objectCopy := object
+
+		if !k.kind.include(&objectCopy) {
+			continue
+		}
+
+		kr, err := knowledgeObjectResource(ctx, k.kind, &objectCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, kr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (k *knowledgeObjectResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	noun := strings.ToLower(k.resourceType.DisplayName)
+
+	var rv []*v2.Entitlement
+	rv = append(rv, ent.NewPermissionEntitlement(
+		resource,
+		knowledgeObjectOwner,
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s %s owner", resource.DisplayName, noun)),
+		ent.WithDescription(fmt.Sprintf("Owner of the %s, whose permissions it runs with", noun)),
+	))
+	rv = append(rv, ent.NewPermissionEntitlement(
+		resource,
+		readPerm,
+		ent.WithGrantableTo(resourceTypeRole),
+		ent.WithDisplayName(fmt.Sprintf("%s %s READ", resource.DisplayName, noun)),
+		ent.WithDescription(fmt.Sprintf("Roles allowed to see the %s", noun)),
+	))
+	rv = append(rv, ent.NewPermissionEntitlement(
+		resource,
+		writePerm,
+		ent.WithGrantableTo(resourceTypeRole),
+		ent.WithDisplayName(fmt.Sprintf("%s %s WRITE", resource.DisplayName, noun)),
+		ent.WithDescription(fmt.Sprintf("Roles allowed to change the %s", noun)),
+	))
+
+	return rv, "", nil, nil
+}
+
+// Grants grants ownership to the owner of the object, and read and write permissions to the roles in its ACL.
+// Private objects are only visible to their owner, so their ACL is ignored.
+func (k *knowledgeObjectResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	client, err := k.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	id, err := objectName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	sharing, app, name, err := parseKnowledgeObjectID(id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	object, err := k.kind.get(client, ctx, app, sharing, name)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get %s: %w", k.resourceType.Id, err)
+	}
+
+	var rv []*v2.Grant
+	if object.ACL.Owner != "" && object.ACL.Owner != splunk.NobodyOwner {
+		ownerID := &v2.ResourceId{
+			ResourceType: resourceTypeUser.Id,
+			Resource:     namespacedID(client.Deployment(), object.ACL.Owner),
+		}
+
+		rv = append(rv, grant.NewGrant(
+			resource,
+			knowledgeObjectOwner,
+			ownerID,
+			grant.WithGrantMetadata(map[string]interface{}{
+				"sharing":   object.ACL.Sharing,
+				"scheduled": object.Content.IsScheduled,
+				"disabled":  object.Content.Disabled,
+			}),
+		))
+	}
+
+	readRoles, writeRoles := object.ACL.Perms.Read, object.ACL.Perms.Write
+	if object.ACL.Sharing == splunk.SharingUser || (len(readRoles) == 0 && len(writeRoles) == 0) {
+		return rv, "", nil, nil
+	}
+
+	roles, err := k.clients.cache.allRoles(ctx, client)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
+	}
+
+	aclRoleGrants, err := aclGrants(ctx, client, resource, roles, readRoles, writeRoles)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv = append(rv, aclRoleGrants...)
+
+	return rv, "", nil, nil
+}
+
+// reassignKnowledgeObjects hands every knowledge object owned by a user over to another user, and
+// returns the objects it moved. Views are moved whether they are dashboards or not. In dry run mode
+// the objects are only listed.
+func reassignKnowledgeObjects(ctx context.Context, client *splunk.Client, from string, to string, dryRun bool) ([]string, error) {
+	l := ctxzap.Extract(ctx)
+
+	var rv []string
+	for _, kind := range knowledgeObjectKinds {
+		// collect every object first, as moving them changes the pages of the listing
+		var objects []splunk.KnowledgeObject
+
+		page := ""
+		for {
+			pageObjects, nextPage, err := kind.listByOwner(
+				client,
+				ctx,
+				from,
+				splunk.PaginationVars{
+					Limit: ResourcesPageSize,
+					Page:  page,
+				},
+			)
+			if err != nil {
+				return rv, fmt.Errorf("splunk-connector: failed to list %s resources of user %s: %w", kind.resourceType.Id, from, err)
+			}
+
+			objects = append(objects, pageObjects...)
+
+			if nextPage == "" {
+				break
+			}
+
+			page = nextPage
+		}
+
+		for _, object := range objects {
+			objectCopy := object
+			description := fmt.Sprintf("%s %s/%s", kind.resourceType.Id, object.ACL.App, object.Name)
+
+			if !dryRun {
+				err := kind.updateOwner(client, ctx, &objectCopy, to)
+				if err != nil {
+					return rv, fmt.Errorf("splunk-connector: failed to reassign %s to %s: %w", description, to, err)
+				}
+			}
+
+			l.Info(
+				"splunk-connector: reassigned knowledge object",
+				zap.String("object", description),
+				zap.String("from", from),
+				zap.String("to", to),
+				zap.Bool("dry_run", dryRun),
+			)
+
+			rv = append(rv, description)
+		}
+	}
+
+	return rv, nil
+}
+
+// knowledgeObjectID identifies a knowledge object by its sharing level, app and name. The owner is left out,
+// since reassigning the object to another user mustn't turn it into a new resource. Each part is escaped,
+// as names of knowledge objects may contain the separators of resource IDs.
+func knowledgeObjectID(sharing string, app string, name string) string {
+	return strings.Join(
+		[]string{url.QueryEscape(sharing), url.QueryEscape(app), url.QueryEscape(name)},
+		knowledgeObjectSeparator,
+	)
+}
+
+// parseKnowledgeObjectID splits an ID created by knowledgeObjectID into the sharing level, app and name of the object.
+func parseKnowledgeObjectID(id string) (string, string, string, error) {
+	parts := strings.Split(id, knowledgeObjectSeparator)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("splunk-connector: failed to parse knowledge object id: %s", id)
+	}
+
+	unescaped := make([]string, 0, len(parts))
+	for _, part := range parts {
+		value, err := url.QueryUnescape(part)
+		if err != nil {
+			return "", "", "", fmt.Errorf("splunk-connector: failed to parse knowledge object id %s: %w", id, err)
+		}
+
+		unescaped = append(unescaped, value)
+	}
+
+	return unescaped[0], unescaped[1], unescaped[2], nil
+}
+
+// describeOwnership describes who owns a knowledge object and who it is shared with.
+func describeOwnership(object *splunk.KnowledgeObject) string {
+	var sharing string
+	switch object.ACL.Sharing {
+	case splunk.SharingUser:
+		sharing = "private"
+	case splunk.SharingApp:
+		sharing = "shared in app"
+	case splunk.SharingGlobal:
+		sharing = "shared globally"
+	default:
+		sharing = fmt.Sprintf("shared as %s", object.ACL.Sharing)
+	}
+
+	return fmt.Sprintf("owned by %s, %s", object.ACL.Owner, sharing)
+}
+
+func savedSearchBuilder(clients *clientRegistry) *knowledgeObjectResourceType {
+	return &knowledgeObjectResourceType{
+		resourceType: resourceTypeSavedSearch,
+		clients:      clients,
+		kind:         savedSearchKind,
+	}
+}
+
+func dashboardBuilder(clients *clientRegistry) *knowledgeObjectResourceType {
+	return &knowledgeObjectResourceType{
+		resourceType: resourceTypeDashboard,
+		clients:      clients,
+		kind:         dashboardKind,
+	}
+}
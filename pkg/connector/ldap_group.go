@@ -0,0 +1,175 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+const ldapGroupMember = "member"
+
+type ldapGroupResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (l *ldapGroupResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return l.resourceType
+}
+
+// ldapGroupResource creates a new connector resource for an LDAP group, scoped under its strategy.
+func ldapGroupResource(ctx context.Context, group *splunk.LDAPGroup, deployment string) (*v2.Resource, error) {
+	profile := map[string]interface{}{
+		"group_name":     group.GroupName(),
+		"group_strategy": group.Strategy(),
+		"group_roles":    strings.Join(group.Content.Roles, ","),
+	}
+
+	resource, err := rs.NewGroupResource(
+		group.GroupName(),
+		resourceTypeLDAPGroup,
+		// group names are usually distinguished names, which may contain the deployment separator
+		namespacedID(deployment, url.QueryEscape(group.Name)),
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+		rs.WithParentResourceID(&v2.ResourceId{
+			ResourceType: resourceTypeLDAPStrategy.Id,
+			Resource:     namespacedID(deployment, group.Strategy()),
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// ldapGroupName returns the `<strategy>,<group>` name of the LDAP group a resource ID created by ldapGroupResource points to.
+func ldapGroupName(resourceID *v2.ResourceId, deployment string) (string, error) {
+	name, err := objectName(resourceID, deployment)
+	if err != nil {
+		return "", err
+	}
+
+	groupName, err := url.QueryUnescape(name)
+	if err != nil {
+		return "", fmt.Errorf("splunk-connector: failed to parse LDAP group id %s: %w", resourceID.Resource, err)
+	}
+
+	return groupName, nil
+}
+
+func (l *ldapGroupResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// LDAP groups are only listed under the strategy they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeLDAPStrategy.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := l.clients.clientFor(&v2.Resource{Id: parentID})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	strategy, err := objectName(parentID, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeLDAPGroup.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	groups, nextPage, err := client.GetLDAPStrategyGroups(
+		ctx,
+		strategy,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list LDAP groups: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		groupCopy := group
+
+		gr, err := ldapGroupResource(ctx, &groupCopy, client.Deployment())
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, gr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (l *ldapGroupResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	entitlementOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s LDAP group member", resource.DisplayName)),
+		ent.WithDescription(fmt.Sprintf("Member of the %s LDAP group, getting the roles mapped to it", resource.DisplayName)),
+	}
+
+	rv = append(rv, ent.NewAssignmentEntitlement(resource, ldapGroupMember, entitlementOptions...))
+
+	return rv, "", nil, nil
+}
+
+// Grants lists the users of the group as Splunk last saw them in the directory.
+// Membership is managed in the directory itself, so it can't be granted or revoked.
+func (l *ldapGroupResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	client, err := l.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	groupName, err := ldapGroupName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	group, err := client.GetLDAPGroup(ctx, groupName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get LDAP group: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, userName := range group.Content.Users {
+		rv = append(rv, grant.NewGrant(
+			resource,
+			ldapGroupMember,
+			&v2.ResourceId{
+				ResourceType: resourceTypeUser.Id,
+				Resource:     namespacedID(client.Deployment(), userName),
+			},
+		))
+	}
+
+	return rv, "", nil, nil
+}
+
+func ldapGroupBuilder(clients *clientRegistry) *ldapGroupResourceType {
+	return &ldapGroupResourceType{
+		resourceType: resourceTypeLDAPGroup,
+		clients:      clients,
+	}
+}
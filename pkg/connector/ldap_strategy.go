@@ -0,0 +1,111 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+type ldapStrategyResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (l *ldapStrategyResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return l.resourceType
+}
+
+// ldapStrategyResource creates a new connector resource for an LDAP strategy, under which its groups are scoped.
+func ldapStrategyResource(ctx context.Context, strategy *splunk.LDAPStrategy, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: LDAP strategy %s is missing its deployment", strategy.Name)
+	}
+
+	description := "LDAP strategy"
+	if strategy.Content.Host != "" {
+		description = fmt.Sprintf("LDAP strategy authenticating against %s", strategy.Content.Host)
+	}
+
+	resource, err := rs.NewResource(
+		strategy.Name,
+		resourceTypeLDAPStrategy,
+		namespacedID(parentResourceID.Resource, strategy.Name),
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(description),
+		rs.WithAnnotation(
+			&v2.ChildResourceType{ResourceTypeId: resourceTypeLDAPGroup.Id},
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (l *ldapStrategyResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// LDAP strategies are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := l.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeLDAPStrategy.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	strategies, nextPage, err := client.GetLDAPStrategies(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list LDAP strategies: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(strategies))
+	for _, strategy := range strategies {
+		strategyCopy := strategy
+
+		sr, err := ldapStrategyResource(ctx, &strategyCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, sr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (l *ldapStrategyResourceType) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func (l *ldapStrategyResourceType) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func ldapStrategyBuilder(clients *clientRegistry) *ldapStrategyResourceType {
+	return &ldapStrategyResourceType{
+		resourceType: resourceTypeLDAPStrategy,
+		clients:      clients,
+	}
+}
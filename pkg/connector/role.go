@@ -14,13 +14,17 @@ import (
 	"github.com/conductorone/baton-splunk/pkg/splunk"
 	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 const roleMember = "member"
 
+// invalidRoleNameCharacters can't appear in Splunk role names, which also have to be lowercase.
+const invalidRoleNameCharacters = " :;/"
+
 type roleResourceType struct {
 	resourceType *v2.ResourceType
-	client       *splunk.Client
+	clients      *clientRegistry
 }
 
 func (r *roleResourceType) ResourceType(_ context.Context) *v2.ResourceType {
@@ -34,6 +38,10 @@ func roleResource(ctx context.Context, role *splunk.Role, parentResourceID *v2.R
 		return nil, fmt.Errorf("splunk-connector: %w", err)
 	}
 
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: role %s is missing its deployment", role.Name)
+	}
+
 	displayName := titleCase(role.Name)
 
 	// merge role.capabilities and role.imported_capabilities and join into a string
@@ -42,15 +50,16 @@ func roleResource(ctx context.Context, role *splunk.Role, parentResourceID *v2.R
 	roleCapabilitiesString := strings.Join(roleCapabilities, ",")
 
 	profile := map[string]interface{}{
-		"role_id":           roleID,
-		"role_name":         role.Name,
-		"role_capabilities": roleCapabilitiesString,
+		"role_id":             roleID,
+		"role_name":           role.Name,
+		"role_capabilities":   roleCapabilitiesString,
+		"role_imported_roles": strings.Join(role.Content.ImportedRoles, ","),
 	}
 
 	resource, err := rs.NewGroupResource(
 		displayName,
 		resourceTypeRole,
-		roleID,
+		namespacedID(parentResourceID.Resource, roleID),
 		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
 		rs.WithParentResourceID(parentResourceID),
 	)
@@ -62,16 +71,22 @@ func roleResource(ctx context.Context, role *splunk.Role, parentResourceID *v2.R
 }
 
 func (r *roleResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	if parentID != nil {
+	// Roles are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
 		return nil, "", nil, nil
 	}
 
+	client, err := r.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
 	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeRole.Id})
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	roles, nextPage, err := r.client.GetRoles(
+	roles, nextPage, err := client.GetRoles(
 		ctx,
 		splunk.PaginationVars{
 			Limit: ResourcesPageSize,
@@ -106,9 +121,9 @@ func (r *roleResourceType) Entitlements(_ context.Context, resource *v2.Resource
 	var rv []*v2.Entitlement
 
 	entitlementOptions := []ent.EntitlementOption{
-		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithGrantableTo(resourceTypeUser, resourceTypeRole, resourceTypeSAMLGroup, resourceTypeLDAPGroup),
 		ent.WithDisplayName(fmt.Sprintf("%s role", resource.DisplayName)),
-		ent.WithDescription(fmt.Sprintf("%s Splunk role", resource.DisplayName)),
+		ent.WithDescription(fmt.Sprintf("%s Splunk role, held by its users, inherited by roles importing it and mapped to SAML and LDAP groups", resource.DisplayName)),
 	}
 
 	rv = append(rv, ent.NewAssignmentEntitlement(resource, roleMember, entitlementOptions...))
@@ -116,8 +131,9 @@ func (r *roleResourceType) Entitlements(_ context.Context, resource *v2.Resource
 	return rv, "", nil, nil
 }
 
+// Grants lists the users holding the role, followed by the roles importing it and the SAML and LDAP groups mapped to it.
 func (r *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, pt *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeUser.Id})
+	bag, err := parseMultiPageToken(pt.Token, resourceTypeUser.Id, resourceTypeRole.Id, resourceTypeSAMLGroup.Id, resourceTypeLDAPGroup.Id)
 	if err != nil {
 		return nil, "", nil, err
 	}
@@ -132,7 +148,33 @@ func (r *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, pt
 		return nil, "", nil, fmt.Errorf("splunk-connector: error parsing role name from role profile")
 	}
 
-	users, nextPage, err := r.client.GetUsersByRole(
+	client, err := r.clients.clientFor(resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	switch bag.ResourceTypeID() {
+	case resourceTypeUser.Id:
+		return r.userGrants(ctx, client, resource, roleName, bag)
+	case resourceTypeRole.Id:
+		return r.importingRoleGrants(ctx, client, resource, roleName, bag)
+	case resourceTypeSAMLGroup.Id:
+		return r.samlGroupGrants(ctx, client, resource, roleName, bag)
+	case resourceTypeLDAPGroup.Id:
+		return r.ldapGroupGrants(ctx, client, resource, roleName, bag)
+	default:
+		return nil, "", nil, fmt.Errorf("splunk-connector: unexpected resource type while listing role grants: %s", bag.ResourceTypeID())
+	}
+}
+
+func (r *roleResourceType) userGrants(
+	ctx context.Context,
+	client *splunk.Client,
+	resource *v2.Resource,
+	roleName string,
+	bag *pagination.Bag,
+) ([]*v2.Grant, string, annotations.Annotations, error) {
+	users, nextPage, err := client.GetUsersByRole(
 		ctx,
 		splunk.PaginationVars{
 			Limit: ResourcesPageSize,
@@ -153,7 +195,7 @@ func (r *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, pt
 	for _, user := range users {
 		userCopy := user
 
-		ur, err := userResource(ctx, &userCopy, resource.ParentResourceId)
+		ur, err := userResource(ctx, &userCopy, deploymentResourceID(client.Deployment()))
 		if err != nil {
 			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build user resource: %w", err)
 		}
@@ -168,23 +210,178 @@ func (r *roleResourceType) Grants(ctx context.Context, resource *v2.Resource, pt
 	return rv, pageToken, nil, nil
 }
 
+// importingRoleGrants grants the role to every role that lists it in imported_roles. The grants are expandable,
+// so members of the importing role are members of the imported one as well.
+func (r *roleResourceType) importingRoleGrants(
+	ctx context.Context,
+	client *splunk.Client,
+	resource *v2.Resource,
+	roleName string,
+	bag *pagination.Bag,
+) ([]*v2.Grant, string, annotations.Annotations, error) {
+	roles, err := r.clients.cache.allRoles(ctx, client)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get roles: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, role := range roles {
+		if !isResourcePresent(role.Content.ImportedRoles, roleName) {
+			continue
+		}
+
+		roleCopy := role
+
+		rr, err := roleResource(ctx, &roleCopy, deploymentResourceID(client.Deployment()))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build role resource: %w", err)
+		}
+
+		rv = append(rv, roleMemberGrant(resource, roleMember, rr))
+	}
+
+	// all importing roles come in a single page, so move on to the next kind of grants
+	pageToken, err := bag.NextToken("")
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+// roleMemberGrant grants an entitlement to a role, expanded to the members of the role so that its users and the
+// roles importing it hold the entitlement too.
+func roleMemberGrant(resource *v2.Resource, entitlementName string, role *v2.Resource, opts ...grant.GrantOption) *v2.Grant {
+	return memberGrant(resource, entitlementName, role, roleMember, opts...)
+}
+
+// memberGrant grants an entitlement to a principal, expanded to the holders of the given entitlement of the principal.
+func memberGrant(
+	resource *v2.Resource,
+	entitlementName string,
+	principal *v2.Resource,
+	memberEntitlement string,
+	opts ...grant.GrantOption,
+) *v2.Grant {
+	opts = append(opts, grant.WithAnnotation(&v2.GrantExpandable{
+		EntitlementIds: []string{ent.NewEntitlementID(principal, memberEntitlement)},
+	}))
+
+	return grant.NewGrant(resource, entitlementName, principal.Id, opts...)
+}
+
+// samlGroupGrants grants the role to every SAML group mapped to it. The grants are expandable, so members of the
+// group would be members of the role as well.
+func (r *roleResourceType) samlGroupGrants(
+	ctx context.Context,
+	client *splunk.Client,
+	resource *v2.Resource,
+	roleName string,
+	bag *pagination.Bag,
+) ([]*v2.Grant, string, annotations.Annotations, error) {
+	groups, err := r.clients.cache.samlGroupsMappedTo(ctx, client, roleName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get SAML groups: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, group := range groups {
+		groupCopy := group
+
+		gr, err := samlGroupResource(ctx, &groupCopy, deploymentResourceID(client.Deployment()))
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build SAML group resource: %w", err)
+		}
+
+		rv = append(rv, memberGrant(resource, roleMember, gr, samlGroupMember))
+	}
+
+	// all mapped groups come in a single page, so move on to the next kind of grants
+	pageToken, err := bag.NextToken("")
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+// ldapGroupGrants grants the role to every LDAP group mapped to it. The grants are expandable, so the users
+// Splunk found in the group are members of the role as well.
+func (r *roleResourceType) ldapGroupGrants(
+	ctx context.Context,
+	client *splunk.Client,
+	resource *v2.Resource,
+	roleName string,
+	bag *pagination.Bag,
+) ([]*v2.Grant, string, annotations.Annotations, error) {
+	groups, err := r.clients.cache.ldapGroupsMappedTo(ctx, client, roleName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get LDAP groups: %w", err)
+	}
+
+	var rv []*v2.Grant
+	for _, group := range groups {
+		groupCopy := group
+
+		gr, err := ldapGroupResource(ctx, &groupCopy, client.Deployment())
+		if err != nil {
+			return nil, "", nil, fmt.Errorf("splunk-connector: failed to build LDAP group resource: %w", err)
+		}
+
+		rv = append(rv, memberGrant(resource, roleMember, gr, ldapGroupMember))
+	}
+
+	// all mapped groups come in a single page, so move on to the next kind of grants
+	pageToken, err := bag.NextToken("")
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return rv, pageToken, nil, nil
+}
+
 func (r *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
-	if principal.Id.ResourceType != resourceTypeUser.Id {
+	if !isRolePrincipal(principal) {
 		l.Warn(
-			"splunk-connector: only users can be granted role membership",
+			"splunk-connector: only users, roles, SAML groups and LDAP groups can be granted role membership",
 			zap.String("principal_id", principal.Id.Resource),
 			zap.String("principal_type", principal.Id.ResourceType),
 		)
 
-		return nil, fmt.Errorf("splunk-connector: only users can be granted role membership")
+		return nil, fmt.Errorf("splunk-connector: only users, roles, SAML groups and LDAP groups can be granted role membership")
 	}
 
-	roleId := entitlement.Resource.Id.Resource
+	client, err := r.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	roleId, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Id.ResourceType == resourceTypeSAMLGroup.Id {
+		return nil, r.grantSAMLGroupRole(ctx, client, principal.Id, roleId)
+	}
+
+	if principal.Id.ResourceType == resourceTypeLDAPGroup.Id {
+		return nil, r.grantLDAPGroupRole(ctx, client, principal.Id, roleId)
+	}
+
+	principalName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Id.ResourceType == resourceTypeRole.Id {
+		return nil, r.grantImportedRole(ctx, client, principalName, roleId)
+	}
 
 	// get existing roles under user
-	user, err := r.client.GetUser(ctx, principal.Id.Resource)
+	user, err := client.GetUser(ctx, principalName)
 	if err != nil {
 		return nil, fmt.Errorf("splunk-connector: failed to find user: %w", err)
 	}
@@ -198,7 +395,7 @@ func (r *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, en
 	user.Content.Roles = append(user.Content.Roles, roleId)
 
 	// grant role membership
-	err = r.client.UpdateUserRoles(ctx, principal.Id.Resource, user.Content.Roles)
+	err = client.UpdateUserRoles(ctx, principalName, user.Content.Roles)
 	if err != nil {
 		return nil, fmt.Errorf("splunk-connector: failed to grant role membership: %w", err)
 	}
@@ -206,26 +403,127 @@ func (r *roleResourceType) Grant(ctx context.Context, principal *v2.Resource, en
 	return nil, nil
 }
 
+// grantImportedRole makes the principal role inherit the target role.
+func (r *roleResourceType) grantImportedRole(ctx context.Context, client *splunk.Client, principalRoleId string, roleId string) error {
+	if principalRoleId == roleId {
+		return fmt.Errorf("splunk-connector: role %s can't import itself", roleId)
+	}
+
+	// get existing imported roles under role
+	principalRole, err := client.GetRole(ctx, principalRoleId)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	// check if role is already imported
+	if isResourcePresent(principalRole.Content.ImportedRoles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s already imported by role %s", roleId, principalRoleId)
+	}
+
+	// merge new role into existing imported roles
+	principalRole.Content.ImportedRoles = append(principalRole.Content.ImportedRoles, roleId)
+
+	err = client.UpdateRoleImportedRoles(ctx, principalRoleId, principalRole.Content.ImportedRoles)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to grant role inheritance: %w", err)
+	}
+
+	return nil
+}
+
+// grantSAMLGroupRole adds the role to the roles mapped to a SAML group.
+func (r *roleResourceType) grantSAMLGroupRole(ctx context.Context, client *splunk.Client, groupID *v2.ResourceId, roleId string) error {
+	groupName, err := samlGroupName(groupID, client.Deployment())
+	if err != nil {
+		return err
+	}
+
+	group, err := client.GetSAMLGroup(ctx, groupName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find SAML group: %w", err)
+	}
+
+	if isResourcePresent(group.Content.Roles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s already mapped to SAML group %s", roleId, groupName)
+	}
+
+	err = client.UpdateSAMLGroupRoles(ctx, groupName, append(group.Content.Roles, roleId))
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to map role to SAML group: %w", err)
+	}
+
+	return nil
+}
+
+// grantLDAPGroupRole adds the role to the roles mapped to an LDAP group.
+func (r *roleResourceType) grantLDAPGroupRole(ctx context.Context, client *splunk.Client, groupID *v2.ResourceId, roleId string) error {
+	groupName, err := ldapGroupName(groupID, client.Deployment())
+	if err != nil {
+		return err
+	}
+
+	group, err := client.GetLDAPGroup(ctx, groupName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find LDAP group: %w", err)
+	}
+
+	if isResourcePresent(group.Content.Roles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s already mapped to LDAP group %s", roleId, groupName)
+	}
+
+	err = client.UpdateLDAPGroupRoles(ctx, groupName, append(group.Content.Roles, roleId))
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to map role to LDAP group: %w", err)
+	}
+
+	return nil
+}
+
 func (r *roleResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
 	l := ctxzap.Extract(ctx)
 
 	entitlement := grant.Entitlement
 	principal := grant.Principal
 
-	if principal.Id.ResourceType != resourceTypeUser.Id {
+	if !isRolePrincipal(principal) {
 		l.Warn(
-			"splunk-connector: only users can have role membership revoked",
+			"splunk-connector: only users, roles, SAML groups and LDAP groups can have role membership revoked",
 			zap.String("principal_id", principal.Id.Resource),
 			zap.String("principal_type", principal.Id.ResourceType),
 		)
 
-		return nil, fmt.Errorf("splunk-connector: only users can have role membership revoked")
+		return nil, fmt.Errorf("splunk-connector: only users, roles, SAML groups and LDAP groups can have role membership revoked")
+	}
+
+	client, err := r.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
 	}
 
-	roleId := entitlement.Resource.Id.Resource
+	roleId, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Id.ResourceType == resourceTypeSAMLGroup.Id {
+		return nil, r.revokeSAMLGroupRole(ctx, client, principal.Id, roleId)
+	}
+
+	if principal.Id.ResourceType == resourceTypeLDAPGroup.Id {
+		return nil, r.revokeLDAPGroupRole(ctx, client, principal.Id, roleId)
+	}
+
+	principalName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Id.ResourceType == resourceTypeRole.Id {
+		return nil, r.revokeImportedRole(ctx, client, principalName, roleId)
+	}
 
 	// get existing roles under user
-	user, err := r.client.GetUser(ctx, principal.Id.Resource)
+	user, err := client.GetUser(ctx, principalName)
 	if err != nil {
 		return nil, fmt.Errorf("splunk-connector: failed to find user: %w", err)
 	}
@@ -239,7 +537,7 @@ func (r *roleResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotat
 	user.Content.Roles = removeResource(user.Content.Roles, roleId)
 
 	// revoke role membership
-	err = r.client.UpdateUserRoles(ctx, principal.Id.Resource, user.Content.Roles)
+	err = client.UpdateUserRoles(ctx, principalName, user.Content.Roles)
 	if err != nil {
 		return nil, fmt.Errorf("splunk-connector: failed to revoke role membership: %w", err)
 	}
@@ -247,9 +545,263 @@ func (r *roleResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotat
 	return nil, nil
 }
 
-func roleBuilder(client *splunk.Client) *roleResourceType {
+// revokeImportedRole stops the principal role from inheriting the target role.
+func (r *roleResourceType) revokeImportedRole(ctx context.Context, client *splunk.Client, principalRoleId string, roleId string) error {
+	// get existing imported roles under role
+	principalRole, err := client.GetRole(ctx, principalRoleId)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	// check if role is present in role's imported roles
+	if !isResourcePresent(principalRole.Content.ImportedRoles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s not imported by role %s", roleId, principalRoleId)
+	}
+
+	// remove role from existing imported roles
+	principalRole.Content.ImportedRoles = removeResource(principalRole.Content.ImportedRoles, roleId)
+
+	err = client.UpdateRoleImportedRoles(ctx, principalRoleId, principalRole.Content.ImportedRoles)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to revoke role inheritance: %w", err)
+	}
+
+	return nil
+}
+
+// revokeSAMLGroupRole removes the role from the roles mapped to a SAML group. Splunk doesn't keep
+// mappings without roles, so the mapping of the group is deleted along with its last role.
+func (r *roleResourceType) revokeSAMLGroupRole(ctx context.Context, client *splunk.Client, groupID *v2.ResourceId, roleId string) error {
+	groupName, err := samlGroupName(groupID, client.Deployment())
+	if err != nil {
+		return err
+	}
+
+	group, err := client.GetSAMLGroup(ctx, groupName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find SAML group: %w", err)
+	}
+
+	if !isResourcePresent(group.Content.Roles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s not mapped to SAML group %s", roleId, groupName)
+	}
+
+	roles := removeResource(group.Content.Roles, roleId)
+	if len(roles) == 0 {
+		err = client.DeleteSAMLGroup(ctx, groupName)
+	} else {
+		err = client.UpdateSAMLGroupRoles(ctx, groupName, roles)
+	}
+
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to unmap role from SAML group: %w", err)
+	}
+
+	return nil
+}
+
+// revokeLDAPGroupRole removes the role from the roles mapped to an LDAP group.
+func (r *roleResourceType) revokeLDAPGroupRole(ctx context.Context, client *splunk.Client, groupID *v2.ResourceId, roleId string) error {
+	groupName, err := ldapGroupName(groupID, client.Deployment())
+	if err != nil {
+		return err
+	}
+
+	group, err := client.GetLDAPGroup(ctx, groupName)
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to find LDAP group: %w", err)
+	}
+
+	if !isResourcePresent(group.Content.Roles, roleId) {
+		return fmt.Errorf("splunk-connector: role %s not mapped to LDAP group %s", roleId, groupName)
+	}
+
+	err = client.UpdateLDAPGroupRoles(ctx, groupName, removeResource(group.Content.Roles, roleId))
+	if err != nil {
+		return fmt.Errorf("splunk-connector: failed to unmap role from LDAP group: %w", err)
+	}
+
+	return nil
+}
+
+// isRolePrincipal reports whether a principal can hold a role.
+func isRolePrincipal(principal *v2.Resource) bool {
+	switch principal.Id.ResourceType {
+	case resourceTypeUser.Id, resourceTypeRole.Id, resourceTypeSAMLGroup.Id, resourceTypeLDAPGroup.Id:
+		return true
+	default:
+		return false
+	}
+}
+
+// Create creates the Splunk role described by the resource on the deployment it is parented to. The group profile
+// takes the role_name, and either role_clone_from to copy an existing role, or the comma separated role_imported_roles,
+// role_capabilities, role_srch_indexes_allowed, role_srch_indexes_default and role_delete_indexes_allowed lists along
+// with role_srch_filter. Without a role_name, the display name is used.
+func (r *roleResourceType) Create(ctx context.Context, resource *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	deployment := ""
+	if resource.GetParentResourceId().GetResourceType() == resourceTypeDeployment.Id {
+		deployment = resource.GetParentResourceId().GetResource()
+	} else {
+		var err error
+		deployment, err = r.clients.onlyDeployment()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var profile *structpb.Struct
+	if groupTrait, err := rs.GetGroupTrait(resource); err == nil {
+		profile = groupTrait.GetProfile()
+	}
+
+	name, ok := rs.GetProfileStringValue(profile, "role_name")
+	if !ok {
+		name = resource.GetDisplayName()
+	}
+
+	var (
+		rr  *v2.Resource
+		err error
+	)
+	if sourceName, ok := rs.GetProfileStringValue(profile, "role_clone_from"); ok {
+		rr, err = r.cloneRole(ctx, deployment, sourceName, name)
+	} else {
+		srchFilter, _ := rs.GetProfileStringValue(profile, "role_srch_filter")
+		rr, err = r.createRole(ctx, deployment, splunk.CreateRoleParams{
+			Name:                 name,
+			ImportedRoles:        profileStrings(profile, "role_imported_roles"),
+			Capabilities:         profileStrings(profile, "role_capabilities"),
+			SrchIndexesAllowed:   profileStrings(profile, "role_srch_indexes_allowed"),
+			SrchIndexesDefault:   profileStrings(profile, "role_srch_indexes_default"),
+			DeleteIndexesAllowed: profileStrings(profile, "role_delete_indexes_allowed"),
+			SrchFilter:           srchFilter,
+		})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rr, nil, nil
+}
+
+// createRole creates a Splunk role on the given deployment and returns its resource.
+func (r *roleResourceType) createRole(ctx context.Context, deployment string, params splunk.CreateRoleParams) (*v2.Resource, error) {
+	client, err := r.clients.writer(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRoleName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := client.CreateRole(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to create role: %w", err)
+	}
+
+	rr, err := roleResource(ctx, role, deploymentResourceID(client.Deployment()))
+	if err != nil {
+		return nil, err
+	}
+
+	return rr, nil
+}
+
+// cloneRole creates a role with the imported roles, capabilities, index allowances and search filter
+// of an existing one. Capabilities the source role inherits stay inherited through its imported roles.
+func (r *roleResourceType) cloneRole(ctx context.Context, deployment string, sourceName string, name string) (*v2.Resource, error) {
+	client, err := r.clients.writer(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := client.GetRole(ctx, sourceName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find role: %w", err)
+	}
+
+	return r.createRole(ctx, deployment, splunk.CreateRoleParams{
+		Name:                 name,
+		ImportedRoles:        source.Content.ImportedRoles,
+		Capabilities:         source.Content.Capabilities,
+		SrchIndexesAllowed:   source.Content.SrchIndexesAllowed,
+		SrchIndexesDefault:   source.Content.SrchIndexesDefault,
+		DeleteIndexesAllowed: source.Content.DeleteIndexesAllowed,
+		SrchFilter:           source.Content.SrchFilter,
+	})
+}
+
+// Delete removes the Splunk role behind the given resource. Users holding the role lose it, and
+// roles importing it stop inheriting from it.
+func (r *roleResourceType) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != resourceTypeRole.Id {
+		return nil, fmt.Errorf("splunk-connector: only roles can be deleted by the role resource type")
+	}
+
+	client, err := r.clients.writerFor(&v2.Resource{Id: resourceId})
+	if err != nil {
+		return nil, err
+	}
+
+	roleName, err := objectName(resourceId, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.DeleteRole(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to delete role: %w", err)
+	}
+
+	return nil, nil
+}
+
+// validateRoleName checks a role name against the rules Splunk enforces when creating a role.
+func validateRoleName(name string) error {
+	if name == "" {
+		return fmt.Errorf("splunk-connector: role name is required to create a role")
+	}
+
+	if name != strings.ToLower(name) || strings.ContainsAny(name, invalidRoleNameCharacters) {
+		return fmt.Errorf("splunk-connector: role name %s has to be lowercase, without spaces, colons, semicolons or slashes", name)
+	}
+
+	return nil
+}
+
+// listAllRoles returns every role of the deployment, going through all pages.
+func listAllRoles(ctx context.Context, client *splunk.Client) ([]splunk.Role, error) {
+	var rv []splunk.Role
+
+	page := ""
+	for {
+		roles, nextPage, err := client.GetRoles(
+			ctx,
+			splunk.PaginationVars{
+				Limit: ResourcesPageSize,
+				Page:  page,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, roles...)
+
+		if nextPage == "" {
+			return rv, nil
+		}
+
+		page = nextPage
+	}
+}
+
+func roleBuilder(clients *clientRegistry) *roleResourceType {
 	return &roleResourceType{
 		resourceType: resourceTypeRole,
-		client:       client,
+		clients:      clients,
 	}
 }
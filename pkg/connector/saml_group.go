@@ -0,0 +1,142 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+const samlGroupMember = "member"
+
+type samlGroupResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (s *samlGroupResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return s.resourceType
+}
+
+// samlGroupResource creates a new connector resource for a SAML group mapped to Splunk roles.
+func samlGroupResource(ctx context.Context, group *splunk.SAMLGroup, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: SAML group %s is missing its deployment", group.Name)
+	}
+
+	profile := map[string]interface{}{
+		"group_name":  group.Name,
+		"group_roles": strings.Join(group.Content.Roles, ","),
+	}
+
+	resource, err := rs.NewGroupResource(
+		group.Name,
+		resourceTypeSAMLGroup,
+		// group names come from the identity provider and may contain the deployment separator
+		namespacedID(parentResourceID.Resource, url.QueryEscape(group.Name)),
+		[]rs.GroupTraitOption{rs.WithGroupProfile(profile)},
+		rs.WithParentResourceID(parentResourceID),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+// samlGroupName returns the name of the SAML group a resource ID created by samlGroupResource points to.
+func samlGroupName(resourceID *v2.ResourceId, deployment string) (string, error) {
+	name, err := objectName(resourceID, deployment)
+	if err != nil {
+		return "", err
+	}
+
+	groupName, err := url.QueryUnescape(name)
+	if err != nil {
+		return "", fmt.Errorf("splunk-connector: failed to parse SAML group id %s: %w", resourceID.Resource, err)
+	}
+
+	return groupName, nil
+}
+
+func (s *samlGroupResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// SAML groups are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := s.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeSAMLGroup.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	groups, nextPage, err := client.GetSAMLGroups(
+		ctx,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list SAML groups: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(groups))
+	for _, group := range groups {
+		groupCopy := group
+
+		gr, err := samlGroupResource(ctx, &groupCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, gr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+// Entitlements returns the membership of the group, which role grants to the group expand from.
+// The roles mapped to a group are granted to it by the role resource type.
+func (s *samlGroupResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	entitlementOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s SAML group member", resource.DisplayName)),
+		ent.WithDescription(fmt.Sprintf("Member of the %s SAML group, getting the roles mapped to it", resource.DisplayName)),
+	}
+
+	rv = append(rv, ent.NewAssignmentEntitlement(resource, samlGroupMember, entitlementOptions...))
+
+	return rv, "", nil, nil
+}
+
+// Grants returns nothing, since group members are only known to the identity provider.
+func (s *samlGroupResourceType) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	return nil, "", nil, nil
+}
+
+func samlGroupBuilder(clients *clientRegistry) *samlGroupResourceType {
+	return &samlGroupResourceType{
+		resourceType: resourceTypeSAMLGroup,
+		clients:      clients,
+	}
+}
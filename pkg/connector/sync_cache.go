@@ -0,0 +1,235 @@
+package connector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+// syncCache holds listings that the grants of many resources are built from, so each is fetched once per deployment
+// and sync. It is reset whenever the deployments are listed, which every sync does before anything else is listed.
+// Each deployment is cached on its own, so a slow deployment doesn't hold up the others.
+type syncCache struct {
+	mu          sync.Mutex
+	deployments map[string]*deploymentCache
+}
+
+// deploymentCache holds the listings of one deployment.
+type deploymentCache struct {
+	roles      listing[[]splunk.Role]
+	lockouts   listing[map[string]bool]
+	samlGroups listing[map[string][]splunk.SAMLGroup]
+	ldapGroups listing[map[string][]splunk.LDAPGroup]
+}
+
+// listing is a value fetched on first use. Callers wait for a fetch in progress, and a failed fetch is
+// tried again by the next caller.
+type listing[T any] struct {
+	mu     sync.Mutex
+	loaded bool
+	value  T
+}
+
+func (l *listing[T]) get(fetch func() (T, error)) (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.loaded {
+		return l.value, nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	l.value, l.loaded = value, true
+
+	return value, nil
+}
+
+func newSyncCache() *syncCache {
+	return &syncCache{
+		deployments: make(map[string]*deploymentCache),
+	}
+}
+
+// reset drops everything cached by the previous sync.
+func (c *syncCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deployments = make(map[string]*deploymentCache)
+}
+
+// deployment returns the listings of the deployment of the client.
+func (c *syncCache) deployment(client *splunk.Client) *deploymentCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.deployments[client.Deployment()]
+	if !ok {
+		d = &deploymentCache{}
+		c.deployments[client.Deployment()] = d
+	}
+
+	return d
+}
+
+// allRoles returns every role of the deployment of the client, listing them on first use.
+func (c *syncCache) allRoles(ctx context.Context, client *splunk.Client) ([]splunk.Role, error) {
+	return c.deployment(client).roles.get(func() ([]splunk.Role, error) {
+		return listAllRoles(ctx, client)
+	})
+}
+
+// lockedOut tells whether the connector locked a user of the deployment of the client, listing the lockouts on first use.
+func (c *syncCache) lockedOut(ctx context.Context, client *splunk.Client, userName string) (bool, error) {
+	locked, err := c.deployment(client).lockouts.get(func() (map[string]bool, error) {
+		locked := make(map[string]bool)
+
+		page := ""
+		for {
+			lockouts, nextPage, err := client.GetLockouts(
+				ctx,
+				splunk.PaginationVars{
+					Limit: ResourcesPageSize,
+					Page:  page,
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, lockout := range lockouts {
+				locked[lockout.Name] = true
+			}
+
+			if nextPage == "" {
+				return locked, nil
+			}
+
+			page = nextPage
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return locked[userName], nil
+}
+
+// samlGroupsMappedTo returns the SAML groups of the deployment of the client that are mapped to a role,
+// listing the mappings of every group on first use.
+func (c *syncCache) samlGroupsMappedTo(ctx context.Context, client *splunk.Client, roleName string) ([]splunk.SAMLGroup, error) {
+	byRole, err := c.deployment(client).samlGroups.get(func() (map[string][]splunk.SAMLGroup, error) {
+		byRole := make(map[string][]splunk.SAMLGroup)
+
+		page := ""
+		for {
+			groups, nextPage, err := client.GetSAMLGroups(
+				ctx,
+				splunk.PaginationVars{
+					Limit: ResourcesPageSize,
+					Page:  page,
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, group := range groups {
+				for _, role := range group.Content.Roles {
+					byRole[role] = append(byRole[role], group)
+				}
+			}
+
+			if nextPage == "" {
+				return byRole, nil
+			}
+
+			page = nextPage
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return byRole[roleName], nil
+}
+
+// ldapGroupsMappedTo returns the LDAP groups of the deployment of the client that are mapped to a role,
+// listing the groups of every strategy on first use.
+func (c *syncCache) ldapGroupsMappedTo(ctx context.Context, client *splunk.Client, roleName string) ([]splunk.LDAPGroup, error) {
+	byRole, err := c.deployment(client).ldapGroups.get(func() (map[string][]splunk.LDAPGroup, error) {
+		byRole := make(map[string][]splunk.LDAPGroup)
+
+		page := ""
+		for {
+			strategies, nextPage, err := client.GetLDAPStrategies(
+				ctx,
+				splunk.PaginationVars{
+					Limit: ResourcesPageSize,
+					Page:  page,
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, strategy := range strategies {
+				groups, err := listAllLDAPStrategyGroups(ctx, client, strategy.Name)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, group := range groups {
+					for _, role := range group.Content.Roles {
+						byRole[role] = append(byRole[role], group)
+					}
+				}
+			}
+
+			if nextPage == "" {
+				return byRole, nil
+			}
+
+			page = nextPage
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return byRole[roleName], nil
+}
+
+// listAllLDAPStrategyGroups returns every group of an LDAP strategy, going through all pages.
+func listAllLDAPStrategyGroups(ctx context.Context, client *splunk.Client, strategy string) ([]splunk.LDAPGroup, error) {
+	var rv []splunk.LDAPGroup
+
+	page := ""
+	for {
+		groups, nextPage, err := client.GetLDAPStrategyGroups(
+			ctx,
+			strategy,
+			splunk.PaginationVars{
+				Limit: ResourcesPageSize,
+				Page:  page,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, groups...)
+
+		if nextPage == "" {
+			return rv, nil
+		}
+
+		page = nextPage
+	}
+}
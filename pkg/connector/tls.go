@@ -0,0 +1,55 @@
+package connector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsConfig builds the TLS configuration of connections to Splunk from the CA bundle, client
+// certificate and `unsafe` settings. It returns nil when the Go defaults should be kept.
+func tlsConfig(unsafe bool, caBundlePath, clientCertPath, clientKeyPath string) (*tls.Config, error) {
+	if !unsafe && caBundlePath == "" && clientCertPath == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	// Skip TLS verification if flag `unsafe` is specified.
+	if unsafe { // #nosec G402
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if caBundlePath != "" {
+		bundle, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to read CA bundle: %w", err)
+		}
+
+		// The bundle is added to the system roots, so Splunk Cloud and the Admin Config Service stay trusted.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("splunk-connector: CA bundle %s contains no PEM certificates", caBundlePath)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if clientCertPath != "" {
+		certificate, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,484 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
+	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
+	rs "github.com/conductorone/baton-sdk/pkg/types/resource"
+	"github.com/conductorone/baton-splunk/pkg/acs"
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const tokenOwner = "owner"
+
+type tokenResourceType struct {
+	resourceType *v2.ResourceType
+	clients      *clientRegistry
+}
+
+func (t *tokenResourceType) ResourceType(_ context.Context) *v2.ResourceType {
+	return t.resourceType
+}
+
+// tokenResource creates a new connector resource for a Splunk authentication token.
+func tokenResource(ctx context.Context, token *splunk.Token, parentResourceID *v2.ResourceId) (*v2.Resource, error) {
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: token %s is missing its deployment", token.Name)
+	}
+
+	claims := token.Content.Claims
+
+	// The audience is the purpose given to the token when it was created.
+	displayName := claims.Audience
+	if displayName == "" {
+		displayName = token.Name
+	}
+
+	description := fmt.Sprintf(
+		"Token of %s, %s, expires %s, last used %s",
+		claims.Subject,
+		token.Content.Status,
+		formatTimestamp(claims.ExpirationTime),
+		formatTimestamp(token.Content.LastUsed),
+	)
+
+	ownerID := &v2.ResourceId{
+		ResourceType: resourceTypeUser.Id,
+		Resource:     namespacedID(parentResourceID.Resource, claims.Subject),
+	}
+
+	// Grants read the owner from the profile, so they don't need to look the token up again.
+	traitOptions := []rs.SecretTraitOption{
+		withSecretProfile(tokenProfile(token)),
+		rs.WithSecretIdentityID(ownerID),
+	}
+
+	if claims.IssuedAt != 0 {
+		traitOptions = append(traitOptions, rs.WithSecretCreatedAt(time.Unix(claims.IssuedAt, 0)))
+	}
+
+	if claims.ExpirationTime != 0 {
+		traitOptions = append(traitOptions, rs.WithSecretExpiresAt(time.Unix(claims.ExpirationTime, 0)))
+	}
+
+	if token.Content.LastUsed != 0 {
+		traitOptions = append(traitOptions, rs.WithSecretLastUsedAt(time.Unix(token.Content.LastUsed, 0)))
+	}
+
+	resource, err := rs.NewSecretResource(
+		fmt.Sprintf("%s (%s)", displayName, claims.Subject),
+		resourceTypeToken,
+		namespacedID(parentResourceID.Resource, token.Name),
+		traitOptions,
+		rs.WithParentResourceID(parentResourceID),
+		rs.WithDescription(description),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return resource, nil
+}
+
+func (t *tokenResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
+	// Tokens are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
+		return nil, "", nil, nil
+	}
+
+	client, err := t.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeToken.Id})
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	tokens, nextPage, err := t.getTokens(
+		ctx,
+		client,
+		splunk.PaginationVars{
+			Limit: ResourcesPageSize,
+			Page:  bag.PageToken(),
+		},
+	)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to list tokens: %w", err)
+	}
+
+	pageToken, err := bag.NextToken(nextPage)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	rv := make([]*v2.Resource, 0, len(tokens))
+	for _, token := range tokens {
+		tokenCopy := token
+
+		tr, err := tokenResource(ctx, &tokenCopy, parentID)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		rv = append(rv, tr)
+	}
+
+	return rv, pageToken, nil, nil
+}
+
+func (t *tokenResourceType) Entitlements(_ context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	entitlementOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s token owner", resource.DisplayName)),
+		ent.WithDescription("User that can authenticate to Splunk with the token"),
+	}
+
+	rv = append(rv, ent.NewPermissionEntitlement(resource, tokenOwner, entitlementOptions...))
+
+	return rv, "", nil, nil
+}
+
+// Grants links a token to the user it authenticates as, whether or not the token can still be used.
+// The status of the token is recorded on the grant and in the profile of the token.
+func (t *tokenResourceType) Grants(ctx context.Context, resource *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	deployment, err := deploymentOf(resource)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: %w", err)
+	}
+
+	traitProfile := resourceTokenProfile(resource)
+	profile := traitProfile.AsMap()
+
+	owner, ok := rs.GetProfileStringValue(traitProfile, "owner")
+	if !ok {
+		// resources listed before tokens had a profile are looked up again
+		token, err := t.getToken(ctx, resource)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		profile = tokenProfile(token)
+		owner = token.Content.Claims.Subject
+	}
+
+	ownerID := &v2.ResourceId{
+		ResourceType: resourceTypeUser.Id,
+		Resource:     namespacedID(deployment, owner),
+	}
+
+	metadata := make(map[string]interface{})
+	for _, key := range []string{"audience", "status", "usable", "issued_at", "expires_at", "last_used", "last_used_ip"} {
+		if value, ok := profile[key]; ok {
+			metadata[key] = value
+		}
+	}
+
+	return []*v2.Grant{
+		grant.NewGrant(
+			resource,
+			tokenOwner,
+			ownerID,
+			grant.WithGrantMetadata(metadata),
+		),
+	}, "", nil, nil
+}
+
+// Grant re-enables a disabled token for the user that owns it. Tokens can't be handed to other users.
+func (t *tokenResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	client, token, owner, err := t.tokenOf(ctx, principal, entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.clients.acsClient(client.Deployment()) != nil {
+		return nil, fmt.Errorf("splunk-connector: the Admin Config Service can't enable tokens of %s, create a new token instead", client.Deployment())
+	}
+
+	if token.Content.Status != splunk.TokenStatusDisabled {
+		return nil, fmt.Errorf("splunk-connector: token %s is already enabled", token.Name)
+	}
+
+	err = client.UpdateTokenStatus(ctx, owner, token.Name, splunk.TokenStatusEnabled)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to enable token: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Revoke disables a token, so that it can be re-enabled if revoked by mistake. Use Delete to remove it.
+func (t *tokenResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	client, token, owner, err := t.tokenOf(ctx, grant.Principal, grant.Entitlement)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.clients.acsClient(client.Deployment()) != nil {
+		return nil, fmt.Errorf("splunk-connector: the Admin Config Service can't disable tokens of %s, delete the token instead", client.Deployment())
+	}
+
+	if token.Content.Status == splunk.TokenStatusDisabled {
+		return nil, fmt.Errorf("splunk-connector: token %s is already disabled", token.Name)
+	}
+
+	err = client.UpdateTokenStatus(ctx, owner, token.Name, splunk.TokenStatusDisabled)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to disable token: %w", err)
+	}
+
+	return nil, nil
+}
+
+// Create isn't supported for tokens, since their secret can only be shown to whoever creates them in Splunk.
+func (t *tokenResourceType) Create(_ context.Context, _ *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	return nil, nil, fmt.Errorf("splunk-connector: tokens can only be created in Splunk")
+}
+
+// Delete removes a token from its deployment, through the Admin Config Service on stacks that have it enabled.
+func (t *tokenResourceType) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != resourceTypeToken.Id {
+		return nil, fmt.Errorf("splunk-connector: only tokens can be deleted by the token resource type")
+	}
+
+	client, err := t.clients.writerFor(&v2.Resource{Id: resourceId})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := objectName(resourceId, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	if acsClient := t.clients.acsClient(client.Deployment()); acsClient != nil {
+		err = acsClient.DeleteToken(ctx, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to delete token: %w", err)
+		}
+
+		return nil, nil
+	}
+
+	token, err := t.lookupToken(ctx, client, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.DeleteToken(ctx, token.Content.Claims.Subject, token.Name)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to delete token: %w", err)
+	}
+
+	return nil, nil
+}
+
+// tokenOf returns the token of an entitlement along with its owner, making sure the principal is that owner.
+func (t *tokenResourceType) tokenOf(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (*splunk.Client, *splunk.Token, string, error) {
+	l := ctxzap.Extract(ctx)
+
+	if principal.Id.ResourceType != resourceTypeUser.Id {
+		l.Warn(
+			"splunk-connector: only users can own tokens",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, nil, "", fmt.Errorf("splunk-connector: only users can own tokens")
+	}
+
+	client, err := t.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	tokenID, err := objectName(entitlement.Resource.Id, client.Deployment())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	userName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	token, err := t.lookupToken(ctx, client, tokenID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if token.Content.Claims.Subject != userName {
+		return nil, nil, "", fmt.Errorf("splunk-connector: token %s belongs to %s, not %s", tokenID, token.Content.Claims.Subject, userName)
+	}
+
+	return client, token, userName, nil
+}
+
+// getTokens lists tokens through the Admin Config Service on Splunk Cloud stacks that have it enabled,
+// and through the management API otherwise.
+func (t *tokenResourceType) getTokens(ctx context.Context, client *splunk.Client, vars splunk.PaginationVars) ([]splunk.Token, string, error) {
+	acsClient := t.clients.acsClient(client.Deployment())
+	if acsClient == nil {
+		return client.GetTokens(ctx, vars)
+	}
+
+	acsTokens, nextPage, err := acsClient.GetTokens(ctx, vars)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tokens := make([]splunk.Token, 0, len(acsTokens))
+	for _, acsToken := range acsTokens {
+		acsTokenCopy := acsToken
+		tokens = append(tokens, tokenFromACS(&acsTokenCopy))
+	}
+
+	return tokens, nextPage, nil
+}
+
+// withSecretProfile sets the profile of a secret trait, which the SDK has no option for.
+func withSecretProfile(profile map[string]interface{}) rs.SecretTraitOption {
+	return func(t *v2.SecretTrait) error {
+		p, err := structpb.NewStruct(profile)
+		if err != nil {
+			return err
+		}
+
+		t.Profile = p
+
+		return nil
+	}
+}
+
+// tokenProfile returns the profile of a token resource, with its status shown along the times it was issued,
+// expires and was last used.
+func tokenProfile(token *splunk.Token) map[string]interface{} {
+	claims := token.Content.Claims
+
+	return map[string]interface{}{
+		"owner":        claims.Subject,
+		"audience":     claims.Audience,
+		"status":       token.Content.Status,
+		"usable":       isTokenUsable(token),
+		"issued_at":    formatTimestamp(claims.IssuedAt),
+		"expires_at":   formatTimestamp(claims.ExpirationTime),
+		"last_used":    formatTimestamp(token.Content.LastUsed),
+		"last_used_ip": token.Content.LastUsedIP,
+	}
+}
+
+// resourceTokenProfile returns the profile of the secret trait of a token resource, if it has one.
+func resourceTokenProfile(resource *v2.Resource) *structpb.Struct {
+	trait := &v2.SecretTrait{}
+	annos := annotations.Annotations(resource.Annotations)
+
+	ok, err := annos.Pick(trait)
+	if err != nil || !ok {
+		return nil
+	}
+
+	return trait.GetProfile()
+}
+
+// getToken looks up the token of a resource on its deployment.
+func (t *tokenResourceType) getToken(ctx context.Context, resource *v2.Resource) (*splunk.Token, error) {
+	client, err := t.clients.clientFor(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := objectName(resource.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	return t.lookupToken(ctx, client, tokenID)
+}
+
+// lookupToken gets a token through the Admin Config Service on Splunk Cloud stacks that have it enabled,
+// like getTokens lists them, and through the management API otherwise.
+func (t *tokenResourceType) lookupToken(ctx context.Context, client *splunk.Client, tokenID string) (*splunk.Token, error) {
+	if acsClient := t.clients.acsClient(client.Deployment()); acsClient != nil {
+		acsToken, err := acsClient.GetToken(ctx, tokenID)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to get token: %w", err)
+		}
+
+		token := tokenFromACS(acsToken)
+
+		return &token, nil
+	}
+
+	token, err := client.GetToken(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to get token: %w", err)
+	}
+
+	return token, nil
+}
+
+// tokenFromACS converts a token listed by the Admin Config Service into the shape of the management API.
+func tokenFromACS(acsToken *acs.Token) splunk.Token {
+	var token splunk.Token
+
+	token.Name = acsToken.ID
+	token.Content.Claims.Subject = acsToken.User
+	token.Content.Claims.Audience = acsToken.Audience
+	token.Content.Claims.IssuedAt = parseACSTimestamp(acsToken.NotBefore)
+	token.Content.Claims.ExpirationTime = parseACSTimestamp(acsToken.ExpiresOn)
+	token.Content.LastUsed = parseACSTimestamp(acsToken.LastUsed)
+	token.Content.LastUsedIP = acsToken.LastUsedIP
+	token.Content.Status = acsToken.Status
+
+	return token
+}
+
+// parseACSTimestamp converts the RFC 3339 timestamps of the Admin Config Service into Unix timestamps,
+// where 0 means never, like the ones of the management API.
+func parseACSTimestamp(timestamp string) int64 {
+	parsed, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return 0
+	}
+
+	return parsed.Unix()
+}
+
+// isTokenUsable reports whether a token is enabled and not expired. Tokens without expiration never expire.
+func isTokenUsable(token *splunk.Token) bool {
+	if token.Content.Status == splunk.TokenStatusDisabled {
+		return false
+	}
+
+	expiration := token.Content.Claims.ExpirationTime
+
+	return expiration == 0 || time.Unix(expiration, 0).After(time.Now())
+}
+
+// formatTimestamp formats the Unix timestamps Splunk uses for tokens, where 0 means never.
+func formatTimestamp(timestamp int64) string {
+	if timestamp == 0 {
+		return "never"
+	}
+
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
+
+func tokenBuilder(clients *clientRegistry) *tokenResourceType {
+	return &tokenResourceType{
+		resourceType: resourceTypeToken,
+		clients:      clients,
+	}
+}
@@ -0,0 +1,313 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+)
+
+// Roles a discovered node plays in the topology of its deployment.
+const (
+	nodeRoleSearchHeadCaptain = "search_head_captain"
+	nodeRoleSearchHeadMember  = "search_head_member"
+	nodeRoleClusterManager    = "cluster_manager"
+	nodeRoleIndexer           = "indexer"
+)
+
+var nodeRoleDescriptions = map[string]string{
+	nodeRoleSearchHeadCaptain: "search head cluster captain",
+	nodeRoleSearchHeadMember:  "search head cluster member",
+	nodeRoleClusterManager:    "indexer cluster manager",
+	nodeRoleIndexer:           "indexer cluster peer",
+}
+
+// topology collects the nodes discovered from the seeds, in discovery order and without duplicates.
+type topology struct {
+	names []string
+	nodes map[string]*deploymentTarget
+	opts  []splunk.ClientOption
+}
+
+// discoverTopology replaces each seed with the nodes of the search head cluster and the indexer cluster
+// it belongs to. Seeds that aren't clustered, and Splunk Cloud stacks, are kept as they are. So are seeds whose
+// clustering can't be read, which only fails discovery for errors other than missing permissions or endpoints.
+func discoverTopology(ctx context.Context, seeds []deploymentTarget, opts ...splunk.ClientOption) ([]deploymentTarget, error) {
+	t := &topology{
+		nodes: make(map[string]*deploymentTarget),
+		opts:  opts,
+	}
+
+	for _, seed := range seeds {
+		if err := t.discover(ctx, seed); err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to discover the topology of %s: %w", seed.name, err)
+		}
+	}
+
+	targets := make([]deploymentTarget, 0, len(t.names))
+	for _, name := range t.names {
+		targets = append(targets, *t.nodes[name])
+	}
+
+	return targets, nil
+}
+
+func (t *topology) discover(ctx context.Context, seed deploymentTarget) error {
+	// Splunk Cloud stacks don't expose their clustering.
+	if seed.cloud {
+		t.add(seed)
+		return nil
+	}
+
+	client := t.client(seed)
+
+	shClusterConfig, err := client.GetSHClusterConfig(ctx)
+	if err != nil {
+		if !skipDiscovery(ctx, seed, "search head cluster", err) {
+			return err
+		}
+
+		t.add(seed)
+	} else if shClusterConfig.Content.Disabled {
+		// The seed is listed among the members of its search head cluster, named after its management URI.
+		t.add(seed)
+	} else if err := t.discoverSearchHeadCluster(ctx, client, seed); err != nil {
+		if !skipDiscovery(ctx, seed, "search head cluster", err) {
+			return err
+		}
+
+		t.add(seed)
+	}
+
+	clusterConfig, err := client.GetClusterConfig(ctx)
+	if err != nil {
+		if !skipDiscovery(ctx, seed, "indexer cluster", err) {
+			return err
+		}
+
+		return nil
+	}
+
+	if clusterConfig.IsManager() {
+		t.add(seed, nodeRoleClusterManager)
+		return t.discoverIndexersOf(ctx, client, seed)
+	}
+
+	if clusterConfig.Content.Mode == splunk.ClusterModeDisabled || clusterConfig.Content.Mode == "" {
+		return nil
+	}
+
+	// search heads attached to several indexer clusters name them instead of giving a URI
+	managerURI := clusterConfig.Manager()
+	if !strings.Contains(managerURI, "://") {
+		ctxzap.Extract(ctx).Warn(
+			"splunk-connector: skipping indexer clusters without a single manager URI",
+			zap.String("deployment", seed.name),
+			zap.String("manager_uri", managerURI),
+		)
+
+		return nil
+	}
+
+	manager, err := nodeTarget(seed, managerURI)
+	if err != nil {
+		return err
+	}
+
+	t.add(manager, nodeRoleClusterManager)
+
+	return t.discoverIndexersOf(ctx, t.client(manager), manager)
+}
+
+// discoverIndexersOf adds the peers of an indexer cluster, keeping the nodes found so far when the
+// manager doesn't let them be listed.
+func (t *topology) discoverIndexersOf(ctx context.Context, managerClient *splunk.Client, manager deploymentTarget) error {
+	err := t.discoverIndexers(ctx, managerClient, manager)
+	if err != nil && !skipDiscovery(ctx, manager, "indexer cluster peers", err) {
+		return err
+	}
+
+	return nil
+}
+
+// skipDiscovery tells whether a discovery step failed because the node doesn't let its clustering be read,
+// either for lack of permissions or because the endpoint isn't available there, and logs it if so.
+func skipDiscovery(ctx context.Context, node deploymentTarget, step string, err error) bool {
+	var apiErr *splunk.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusServiceUnavailable:
+	default:
+		return false
+	}
+
+	ctxzap.Extract(ctx).Warn(
+		"splunk-connector: skipping topology discovery",
+		zap.String("deployment", node.name),
+		zap.String("step", step),
+		zap.Error(err),
+	)
+
+	return true
+}
+
+// discoverSearchHeadCluster adds the members of the search head cluster of the seed. Writes to members
+// are sent to the captain, since configuration changes are replicated from there.
+func (t *topology) discoverSearchHeadCluster(ctx context.Context, client *splunk.Client, seed deploymentTarget) error {
+	captain, err := client.GetSHClusterCaptain(ctx)
+	if err != nil {
+		return err
+	}
+
+	captainNode, err := nodeTarget(seed, captain.Content.MgmtURI)
+	if err != nil {
+		return err
+	}
+
+	page := ""
+	for {
+		members, nextPage, err := client.GetSHClusterMembers(ctx, splunk.PaginationVars{Limit: ResourcesPageSize, Page: page})
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			node, err := nodeTarget(seed, member.Content.MgmtURI)
+			if err != nil {
+				return err
+			}
+
+			if node.name == captainNode.name {
+				t.add(node, nodeRoleSearchHeadCaptain, nodeRoleSearchHeadMember)
+				continue
+			}
+
+			node.writerName = captainNode.name
+			node.writerBaseURL = strings.TrimSuffix(captain.Content.MgmtURI, "/")
+			t.add(node, nodeRoleSearchHeadMember)
+		}
+
+		if nextPage == "" {
+			return nil
+		}
+
+		page = nextPage
+	}
+}
+
+// discoverIndexers adds the peers of the indexer cluster managed by the given manager.
+func (t *topology) discoverIndexers(ctx context.Context, managerClient *splunk.Client, manager deploymentTarget) error {
+	managerURL, err := url.Parse(managerClient.CreateUrl(""))
+	if err != nil {
+		return err
+	}
+
+	page := ""
+	for {
+		peers, nextPage, err := managerClient.GetClusterPeers(ctx, splunk.PaginationVars{Limit: ResourcesPageSize, Page: page})
+		if err != nil {
+			return err
+		}
+
+		for _, peer := range peers {
+			// peers only report their host and management port, reached like their manager
+			node, err := nodeTarget(manager, managerURL.Scheme+"://"+peer.Content.HostPortPair)
+			if err != nil {
+				return err
+			}
+
+			t.add(node, nodeRoleIndexer)
+		}
+
+		if nextPage == "" {
+			return nil
+		}
+
+		page = nextPage
+	}
+}
+
+// add records a node, or adds roles to a node discovered before.
+func (t *topology) add(node deploymentTarget, roles ...string) {
+	existing, ok := t.nodes[node.name]
+	if !ok {
+		nodeCopy := node
+		existing = &nodeCopy
+
+		t.nodes[node.name] = existing
+		t.names = append(t.names, node.name)
+	}
+
+	for _, role := range roles {
+		if !hasNodeRole(existing.roles, role) {
+			existing.roles = append(existing.roles, role)
+		}
+	}
+}
+
+func hasNodeRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *topology) client(target deploymentTarget) *splunk.Client {
+	opts := t.opts
+	if target.baseURL != "" {
+		opts = append(append([]splunk.ClientOption{}, t.opts...), splunk.WithBaseURL(target.baseURL))
+	}
+
+	return splunk.NewClient(target.httpClient, target.auth, target.cloud, target.name, opts...)
+}
+
+// nodeTarget returns the target of a node of the same deployment as seed, reached at its management URI.
+// Nodes listening on the default management port are named after their host, like deployments given by address.
+func nodeTarget(seed deploymentTarget, mgmtURI string) (deploymentTarget, error) {
+	name, baseURL, err := splunk.ParseDeployment(mgmtURI)
+	if err != nil {
+		return deploymentTarget{}, err
+	}
+
+	if baseURL == "" {
+		return deploymentTarget{}, fmt.Errorf("management URI %s is not a URL", mgmtURI)
+	}
+
+	if u, err := url.Parse(baseURL); err == nil && fmt.Sprintf(splunk.BaseURL, u.Hostname()) == baseURL {
+		name, baseURL = u.Hostname(), ""
+	}
+
+	return deploymentTarget{
+		name:       name,
+		baseURL:    baseURL,
+		auth:       seed.auth,
+		httpClient: seed.httpClient,
+	}, nil
+}
+
+// describeNodeRoles returns a description of the roles of a discovered node.
+func describeNodeRoles(roles []string) string {
+	descriptions := make([]string, 0, len(roles))
+	for _, role := range roles {
+		descriptions = append(descriptions, nodeRoleDescriptions[role])
+	}
+
+	description := strings.Join(descriptions, ", ")
+	if description == "" {
+		return ""
+	}
+
+	return strings.ToUpper(description[:1]) + description[1:]
+}
@@ -0,0 +1,58 @@
+package connector
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+func TestNodeTarget(t *testing.T) {
+	seed := deploymentTarget{
+		name:       "splunk.corp",
+		baseURL:    "https://splunk.corp:8443",
+		cloud:      true,
+		auth:       splunk.NewTokenAuth(splunk.StaticSecret("token")),
+		httpClient: http.DefaultClient,
+	}
+
+	tests := []struct {
+		name        string
+		mgmtURI     string
+		wantName    string
+		wantBaseURL string
+		wantErr     bool
+	}{
+		{name: "default port", mgmtURI: "https://sh1.corp:8089", wantName: "sh1.corp"},
+		{name: "other port", mgmtURI: "https://sh1.corp:8443", wantName: "sh1.corp:8443", wantBaseURL: "https://sh1.corp:8443"},
+		{name: "plain http", mgmtURI: "http://sh1.corp:8089", wantName: "sh1.corp:8089", wantBaseURL: "http://sh1.corp:8089"},
+		{name: "trailing slash", mgmtURI: "https://sh1.corp:8089/", wantName: "sh1.corp"},
+		{name: "not a url", mgmtURI: "sh1.corp", wantErr: true},
+		{name: "unsupported scheme", mgmtURI: "ftp://sh1.corp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nodeTarget(seed, tt.mgmtURI)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nodeTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got.name != tt.wantName || got.baseURL != tt.wantBaseURL {
+				t.Errorf("nodeTarget() = %q, %q, want %q, %q", got.name, got.baseURL, tt.wantName, tt.wantBaseURL)
+			}
+
+			if got.cloud {
+				t.Errorf("nodeTarget() must not inherit cloud from the seed")
+			}
+
+			if got.auth != seed.auth || got.httpClient != seed.httpClient {
+				t.Errorf("nodeTarget() must reuse the auth and HTTP client of the seed")
+			}
+		})
+	}
+}
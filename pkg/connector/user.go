@@ -3,17 +3,42 @@ package connector
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	v2 "github.com/conductorone/baton-sdk/pb/c1/connector/v2"
 	"github.com/conductorone/baton-sdk/pkg/annotations"
+	"github.com/conductorone/baton-sdk/pkg/connectorbuilder"
 	"github.com/conductorone/baton-sdk/pkg/pagination"
+	ent "github.com/conductorone/baton-sdk/pkg/types/entitlement"
+	"github.com/conductorone/baton-sdk/pkg/types/grant"
 	"github.com/conductorone/baton-sdk/pkg/types/resource"
 	"github.com/conductorone/baton-splunk/pkg/splunk"
+	"github.com/grpc-ecosystem/go-grpc-middleware/logging/zap/ctxzap"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
+const userLogin = "login"
+
+// deprovisionConfig controls what happens around locking and deleting users.
+type deprovisionConfig struct {
+	// lockoutRole replaces the roles of users whose login is revoked. It should grant no capabilities.
+	lockoutRole string
+
+	// knowledgeObjectOwner takes over the knowledge objects of users before they are locked or deleted.
+	knowledgeObjectOwner string
+
+	// dryRun only lists the knowledge objects that would be reassigned, and leaves users untouched.
+	dryRun bool
+}
+
 type userResourceType struct {
 	resourceType *v2.ResourceType
-	client       *splunk.Client
+	clients      *clientRegistry
+	deprovision  deprovisionConfig
 }
 
 func (u *userResourceType) ResourceType(_ context.Context) *v2.ResourceType {
@@ -27,22 +52,52 @@ func userResource(ctx context.Context, user *splunk.User, parentResourceID *v2.R
 		return nil, fmt.Errorf("splunk-connector: %w", err)
 	}
 
+	if parentResourceID == nil {
+		return nil, fmt.Errorf("splunk-connector: user %s is missing its deployment", user.Name)
+	}
+
+	authType := user.Content.Type
+	if authType == "" {
+		authType = splunk.UserTypeSplunk
+	}
+
 	profile := map[string]interface{}{
-		"login":     user.Content.Email,
-		"user_id":   user.Id,
-		"user_name": user.Name,
+		"login":       user.Content.Email,
+		"user_id":     user.Id,
+		"user_name":   user.Name,
+		"real_name":   user.Content.RealName,
+		"auth_type":   authType,
+		"default_app": user.Content.DefaultApp,
+		"locked_out":  user.Content.LockedOut,
+	}
+
+	// Locked-out users can't sign in until an admin unlocks them.
+	userStatus := v2.UserTrait_Status_STATUS_ENABLED
+	if user.Content.LockedOut {
+		userStatus = v2.UserTrait_Status_STATUS_DISABLED
+	}
+
+	traitOptions := []resource.UserTraitOption{
+		resource.WithEmail(user.Content.Email, true),
+		resource.WithUserProfile(profile),
+		resource.WithStatus(userStatus),
+	}
+
+	// Federated users always map to a person in the identity provider,
+	// while local accounts may be break-glass or service accounts.
+	description := "Local Splunk account"
+	if authType != splunk.UserTypeSplunk {
+		traitOptions = append(traitOptions, resource.WithAccountType(v2.UserTrait_ACCOUNT_TYPE_HUMAN))
+		description = fmt.Sprintf("%s account", authType)
 	}
 
 	ret, err := resource.NewUserResource(
 		user.Name,
 		resourceTypeUser,
-		userID,
-		[]resource.UserTraitOption{
-			resource.WithEmail(user.Content.Email, true),
-			resource.WithUserProfile(profile),
-			resource.WithStatus(v2.UserTrait_Status_STATUS_ENABLED),
-		},
+		namespacedID(parentResourceID.Resource, userID),
+		traitOptions,
 		resource.WithParentResourceID(parentResourceID),
+		resource.WithDescription(description),
 	)
 	if err != nil {
 		return nil, err
@@ -52,16 +107,22 @@ func userResource(ctx context.Context, user *splunk.User, parentResourceID *v2.R
 }
 
 func (u *userResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt *pagination.Token) ([]*v2.Resource, string, annotations.Annotations, error) {
-	if parentID != nil {
+	// Users are only listed under the deployment they belong to.
+	if parentID == nil || parentID.ResourceType != resourceTypeDeployment.Id {
 		return nil, "", nil, nil
 	}
 
+	client, err := u.clients.client(parentID.Resource)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
 	bag, err := parsePageToken(pt.Token, &v2.ResourceId{ResourceType: resourceTypeUser.Id})
 	if err != nil {
 		return nil, "", nil, err
 	}
 
-	users, nextPage, err := u.client.GetUsers(
+	users, nextPage, err := client.GetUsers(
 		ctx,
 		splunk.PaginationVars{
 			Limit: ResourcesPageSize,
@@ -92,17 +153,428 @@ func (u *userResourceType) List(ctx context.Context, parentID *v2.ResourceId, pt
 	return rv, pageToken, nil, nil
 }
 
-func (u *userResourceType) Entitlements(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+func (u *userResourceType) Entitlements(_ context.Context, user *v2.Resource, _ *pagination.Token) ([]*v2.Entitlement, string, annotations.Annotations, error) {
+	var rv []*v2.Entitlement
+
+	entitlementOptions := []ent.EntitlementOption{
+		ent.WithGrantableTo(resourceTypeUser),
+		ent.WithDisplayName(fmt.Sprintf("%s login", user.DisplayName)),
+		ent.WithDescription(fmt.Sprintf("Ability of %s to sign in to Splunk", user.DisplayName)),
+	}
+
+	rv = append(rv, ent.NewPermissionEntitlement(user, userLogin, entitlementOptions...))
+
+	return rv, "", nil, nil
 }
 
-func (u *userResourceType) Grants(_ context.Context, _ *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
-	return nil, "", nil, nil
+// Grants grants login to the user itself, unless Splunk locked the user out or the connector locked them.
+func (u *userResourceType) Grants(ctx context.Context, user *v2.Resource, _ *pagination.Token) ([]*v2.Grant, string, annotations.Annotations, error) {
+	client, err := u.clients.clientFor(user)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	userTrait, err := resource.GetUserTrait(user)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	if userTrait.Status.GetStatus() == v2.UserTrait_Status_STATUS_DISABLED {
+		return nil, "", nil, nil
+	}
+
+	userName, err := objectName(user.Id, client.Deployment())
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	lockedOut, err := u.clients.cache.lockedOut(ctx, client, userName)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("splunk-connector: failed to get lockouts: %w", err)
+	}
+
+	if lockedOut {
+		return nil, "", nil, nil
+	}
+
+	return []*v2.Grant{grant.NewGrant(user, userLogin, user.Id)}, "", nil, nil
 }
 
-func userBuilder(client *splunk.Client) *userResourceType {
+// Grant restores login of a user, either by putting back the roles saved when the connector
+// locked them, or by clearing the lockout Splunk set after failed logins.
+func (u *userResourceType) Grant(ctx context.Context, principal *v2.Resource, entitlement *v2.Entitlement) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	if !proto.Equal(principal.Id, entitlement.Resource.Id) {
+		l.Warn(
+			"splunk-connector: login can only be granted to the user itself",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: login can only be granted to the user itself")
+	}
+
+	client, err := u.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	userName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := client.GetUser(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find user: %w", err)
+	}
+
+	lockout, err := client.GetLockout(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to get lockout of user: %w", err)
+	}
+
+	if lockout == nil && !user.Content.LockedOut {
+		return nil, fmt.Errorf("splunk-connector: user %s is not locked", userName)
+	}
+
+	if lockout != nil {
+		// restore the roles the user had before being locked
+		err = client.UpdateUserRoles(ctx, userName, lockout.SavedRoles())
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to restore roles of user: %w", err)
+		}
+
+		err = client.DeleteLockout(ctx, userName)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to remove lockout of user: %w", err)
+		}
+	}
+
+	if user.Content.LockedOut {
+		err = client.UnlockUser(ctx, userName)
+		if err != nil {
+			return nil, fmt.Errorf("splunk-connector: failed to unlock user: %w", err)
+		}
+	}
+
+	return nil, nil
+}
+
+// Revoke locks a user. Splunk can't lock accounts through its API, so the roles of the user are saved
+// on the instance and replaced with the lockout role, which leaves them unable to do anything once signed in.
+// Only local users can be locked, since Splunk gives SAML and LDAP users the roles of their groups again at
+// their next login.
+func (u *userResourceType) Revoke(ctx context.Context, grant *v2.Grant) (annotations.Annotations, error) {
+	l := ctxzap.Extract(ctx)
+
+	entitlement := grant.Entitlement
+	principal := grant.Principal
+
+	if !proto.Equal(principal.Id, entitlement.Resource.Id) {
+		l.Warn(
+			"splunk-connector: login can only be revoked from the user itself",
+			zap.String("principal_id", principal.Id.Resource),
+			zap.String("principal_type", principal.Id.ResourceType),
+		)
+
+		return nil, fmt.Errorf("splunk-connector: login can only be revoked from the user itself")
+	}
+
+	if u.deprovision.lockoutRole == "" {
+		return nil, fmt.Errorf("splunk-connector: a lockout role has to be configured to revoke login")
+	}
+
+	client, err := u.clients.writerFor(entitlement.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	userName, err := objectName(principal.Id, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := client.GetUser(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find user: %w", err)
+	}
+
+	if user.Content.Type != "" && user.Content.Type != splunk.UserTypeSplunk {
+		return nil, fmt.Errorf(
+			"splunk-connector: login of %s user %s can't be revoked, since Splunk restores its roles from its groups at every login; remove the user from its groups in the identity provider instead",
+			user.Content.Type,
+			userName,
+		)
+	}
+
+	lockout, err := client.GetLockout(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to get lockout of user: %w", err)
+	}
+
+	if lockout != nil {
+		return nil, fmt.Errorf("splunk-connector: user %s is already locked", userName)
+	}
+
+	// the lockout role has to exist before any user is moved into it
+	_, err = client.GetRole(ctx, u.deprovision.lockoutRole)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find lockout role %s: %w", u.deprovision.lockoutRole, err)
+	}
+
+	reassigned, err := u.reassignBeforeDeprovision(ctx, client, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.deprovision.dryRun {
+		return nil, u.dryRunError(ctx, userName, reassigned)
+	}
+
+	// save the current roles so a later grant can restore them
+	err = client.CreateLockout(ctx, userName, user.Content.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to save roles of user: %w", err)
+	}
+
+	err = client.UpdateUserRoles(ctx, userName, []string{u.deprovision.lockoutRole})
+	if err != nil {
+		// don't leave a lockout behind for a user that still has their roles, or later revokes find them locked
+		if deleteErr := client.DeleteLockout(ctx, userName); deleteErr != nil {
+			l.Error("splunk-connector: failed to remove lockout after failed lock", zap.Error(deleteErr))
+
+			return nil, fmt.Errorf(
+				"splunk-connector: failed to lock user: %w, and its saved roles have to be removed from conf-baton_lockout before revoking again: %w",
+				err,
+				deleteErr,
+			)
+		}
+
+		return nil, fmt.Errorf("splunk-connector: failed to lock user: %w", err)
+	}
+
+	return nil, nil
+}
+
+// CreateAccountCapabilityDetails reports that accounts are created with a random password,
+// since local Splunk users can't be created without one.
+func (u *userResourceType) CreateAccountCapabilityDetails(_ context.Context) (*v2.CredentialDetailsAccountProvisioning, annotations.Annotations, error) {
+	return &v2.CredentialDetailsAccountProvisioning{
+		SupportedCredentialOptions: []v2.CapabilityDetailCredentialOption{
+			v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+		},
+		PreferredCredentialOption: v2.CapabilityDetailCredentialOption_CAPABILITY_DETAIL_CREDENTIAL_OPTION_RANDOM_PASSWORD,
+	}, nil, nil
+}
+
+// CreateAccount creates a local Splunk user and returns its generated password, which the user has to change
+// on first login. The profile picks the deployment, which can be left out when there is only one, and can set
+// the real name and the roles of the user.
+func (u *userResourceType) CreateAccount(
+	ctx context.Context,
+	accountInfo *v2.AccountInfo,
+	credentialOptions *v2.CredentialOptions,
+) (connectorbuilder.CreateAccountResponse, []*v2.PlaintextData, annotations.Annotations, error) {
+	if credentialOptions.GetRandomPassword() == nil {
+		return nil, nil, nil, fmt.Errorf("splunk-connector: accounts can only be created with a random password")
+	}
+
+	profile := accountInfo.GetProfile()
+
+	deployment, ok := resource.GetProfileStringValue(profile, "deployment")
+	if !ok {
+		var err error
+		deployment, err = u.clients.onlyDeployment()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	client, err := u.clients.writer(deployment)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	params := splunk.CreateUserParams{
+		Name:                accountInfo.GetLogin(),
+		Roles:               profileStrings(profile, "roles"),
+		ForceChangePassword: true,
+	}
+
+	if params.Name == "" {
+		return nil, nil, nil, fmt.Errorf("splunk-connector: user name is required to create an account")
+	}
+
+	params.RealName, _ = resource.GetProfileStringValue(profile, "realname")
+
+	for _, email := range accountInfo.GetEmails() {
+		if params.Email == "" || email.GetIsPrimary() {
+			params.Email = email.GetAddress()
+		}
+	}
+
+	params.Password, err = generatePassword(int(credentialOptions.GetRandomPassword().GetLength()))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("splunk-connector: failed to generate password: %w", err)
+	}
+
+	user, err := client.CreateUser(ctx, params)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("splunk-connector: failed to create user: %w", err)
+	}
+
+	ur, err := userResource(ctx, user, deploymentResourceID(client.Deployment()))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	passwordResult := &v2.PlaintextData{
+		Name:        "password",
+		Description: "Password the user has to change on first login",
+		Bytes:       []byte(params.Password),
+	}
+
+	return &v2.CreateAccountResponse_SuccessResult{Resource: ur, IsCreateAccountResult: true}, []*v2.PlaintextData{passwordResult}, nil, nil
+}
+
+// Create isn't supported for users, which are created with a password through CreateAccount instead.
+func (u *userResourceType) Create(_ context.Context, _ *v2.Resource) (*v2.Resource, annotations.Annotations, error) {
+	return nil, nil, fmt.Errorf("splunk-connector: users can only be created through account provisioning")
+}
+
+// Delete removes the Splunk user behind the given resource.
+func (u *userResourceType) Delete(ctx context.Context, resourceId *v2.ResourceId) (annotations.Annotations, error) {
+	if resourceId.ResourceType != resourceTypeUser.Id {
+		return nil, fmt.Errorf("splunk-connector: only users can be deleted by the user resource type")
+	}
+
+	client, err := u.clients.writerFor(&v2.Resource{Id: resourceId})
+	if err != nil {
+		return nil, err
+	}
+
+	userName, err := objectName(resourceId, client.Deployment())
+	if err != nil {
+		return nil, err
+	}
+
+	reassigned, err := u.reassignBeforeDeprovision(ctx, client, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.deprovision.dryRun {
+		return nil, u.dryRunError(ctx, userName, reassigned)
+	}
+
+	err = client.DeleteUser(ctx, userName)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to delete user: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (u *userResourceType) reassign(ctx context.Context, client *splunk.Client, userName string) ([]string, error) {
+	newOwner := u.deprovision.knowledgeObjectOwner
+	if newOwner == "" {
+		return nil, fmt.Errorf("splunk-connector: a knowledge object owner has to be configured to reassign knowledge objects")
+	}
+
+	if newOwner == userName {
+		return nil, fmt.Errorf("splunk-connector: user %s is the knowledge object owner and can't be deprovisioned", userName)
+	}
+
+	// objects can't be handed over to a user that doesn't exist
+	_, err := client.GetUser(ctx, newOwner)
+	if err != nil {
+		return nil, fmt.Errorf("splunk-connector: failed to find knowledge object owner %s: %w", newOwner, err)
+	}
+
+	return reassignKnowledgeObjects(ctx, client, userName, newOwner, u.deprovision.dryRun)
+}
+
+// reassignBeforeDeprovision moves the knowledge objects of a user to the configured owner before the user is
+// locked or deleted, so scheduled searches keep running, and returns the objects moved. In dry run mode the
+// objects are only listed.
+func (u *userResourceType) reassignBeforeDeprovision(ctx context.Context, client *splunk.Client, userName string) ([]string, error) {
+	if u.deprovision.knowledgeObjectOwner == "" {
+		return nil, nil
+	}
+
+	return u.reassign(ctx, client, userName)
+}
+
+// dryRunError fails an action left undone by a dry run, so it isn't recorded as done, with the knowledge objects
+// that would have been reassigned from the user in its message and details.
+func (u *userResourceType) dryRunError(ctx context.Context, userName string, objects []string) error {
+	l := ctxzap.Extract(ctx)
+
+	l.Info(
+		"splunk-connector: dry run, user left untouched",
+		zap.String("user", userName),
+		zap.String("knowledge_object_owner", u.deprovision.knowledgeObjectOwner),
+		zap.Int("knowledge_objects", len(objects)),
+	)
+
+	reassigned := make([]interface{}, 0, len(objects))
+	for _, object := range objects {
+		reassigned = append(reassigned, object)
+	}
+
+	details, err := structpb.NewStruct(map[string]interface{}{
+		"dry_run":                true,
+		"user":                   userName,
+		"knowledge_object_owner": u.deprovision.knowledgeObjectOwner,
+		"knowledge_objects":      reassigned,
+	})
+	if err != nil {
+		return err
+	}
+
+	st := status.Newf(
+		codes.FailedPrecondition,
+		"splunk-connector: dry run, user %s left untouched, %d knowledge objects would be reassigned to %s: %s",
+		userName,
+		len(objects),
+		u.deprovision.knowledgeObjectOwner,
+		strings.Join(objects, ", "),
+	)
+
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
+
+func userBuilder(clients *clientRegistry, deprovision deprovisionConfig) *userResourceType {
 	return &userResourceType{
 		resourceType: resourceTypeUser,
-		client:       client,
+		clients:      clients,
+		deprovision:  deprovision,
 	}
 }
+
+// profileStrings returns the strings under the given profile key, which can hold a list or a comma separated string.
+func profileStrings(profile *structpb.Struct, key string) []string {
+	var values []string
+	switch value := profile.GetFields()[key].GetKind().(type) {
+	case *structpb.Value_ListValue:
+		for _, item := range value.ListValue.GetValues() {
+			if item.GetStringValue() != "" {
+				values = append(values, item.GetStringValue())
+			}
+		}
+	case *structpb.Value_StringValue:
+		for _, item := range strings.Split(value.StringValue, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,107 @@
+package offline
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// stanza holds the attributes of a single stanza of a .conf file.
+type stanza map[string]string
+
+// conf holds the stanzas of a .conf file keyed by stanza name.
+// Attributes that appear before the first stanza header belong to the "default" stanza.
+type conf map[string]stanza
+
+// parseConf parses the contents of a Splunk .conf file.
+func parseConf(data []byte) (conf, error) {
+	c := conf{}
+	current := c.stanza("default")
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+
+	var continued string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// A trailing backslash continues the value on the next line.
+		if strings.HasSuffix(line, `\`) {
+			continued += strings.TrimSuffix(line, `\`) + "\n"
+			continue
+		}
+
+		line = continued + line
+		continued = ""
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = c.stanza(strings.TrimSpace(line[1 : len(line)-1]))
+		default:
+			key, value, found := strings.Cut(line, "=")
+			if !found {
+				continue
+			}
+
+			current[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse conf file: %w", err)
+	}
+
+	return c, nil
+}
+
+// stanza returns the stanza with the given name, creating it if needed.
+func (c conf) stanza(name string) stanza {
+	s, ok := c[name]
+	if !ok {
+		s = stanza{}
+		c[name] = s
+	}
+
+	return s
+}
+
+// overlay copies the attributes of a higher precedence layer over the current ones.
+func (c conf) overlay(layer conf) {
+	for name, attributes := range layer {
+		s := c.stanza(name)
+		for key, value := range attributes {
+			s[key] = value
+		}
+	}
+}
+
+// stanzasWithPrefix returns the sorted names of stanzas starting with the given prefix, with the prefix removed.
+func (c conf) stanzasWithPrefix(prefix string) []string {
+	var names []string
+	for name := range c {
+		if strings.HasPrefix(name, prefix) && len(name) > len(prefix) {
+			names = append(names, strings.TrimPrefix(name, prefix))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// splitList splits a list attribute such as `importRoles = power;user` into its items.
+func splitList(value string, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
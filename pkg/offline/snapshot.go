@@ -0,0 +1,595 @@
+package offline
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxFileSize caps the size of a single configuration file read from disk or from an archive.
+const maxFileSize = 32 << 20
+
+const (
+	authorizeConf      = "authorize.conf"
+	authenticationConf = "authentication.conf"
+	appConf            = "app.conf"
+	indexesConf        = "indexes.conf"
+	defaultMeta        = "default.meta"
+	localMeta          = "local.meta"
+	passwdFile         = "passwd"
+
+	rolePrefix       = "role_"
+	capabilityPrefix = "capability::"
+	roleMapPrefix    = "roleMap_"
+	samlRoleMap      = "roleMap_SAML"
+)
+
+// Role is a role defined in authorize.conf.
+type Role struct {
+	Name                 string
+	Capabilities         []string
+	ImportedRoles        []string
+	SrchIndexesAllowed   []string
+	SrchIndexesDefault   []string
+	DeleteIndexesAllowed []string
+	SrchFilter           string
+}
+
+// User is a local user defined in the passwd file.
+type User struct {
+	Name     string
+	RealName string
+	Email    string
+	Roles    []string
+}
+
+// App is an application installed under etc/apps.
+type App struct {
+	Name        string
+	Label       string
+	Description string
+	Version     string
+	Visible     bool
+	Read        []string
+	Write       []string
+}
+
+// Index is an index defined in indexes.conf.
+type Index struct {
+	Name     string
+	DataType string
+	Disabled bool
+}
+
+// RoleMapping maps an external LDAP or SAML group to Splunk roles, as configured in authentication.conf.
+type RoleMapping struct {
+	Strategy string
+	Group    string
+	Roles    []string
+}
+
+// LDAPStrategy is an LDAP server configuration defined in authentication.conf.
+type LDAPStrategy struct {
+	Name        string
+	Host        string
+	UserBaseDN  string
+	GroupBaseDN string
+}
+
+// Snapshot is the access configuration of a Splunk instance read from its etc directory.
+type Snapshot struct {
+	Roles        []Role
+	Users        []User
+	Capabilities []string
+	Apps         []App
+	Indexes      []Index
+	SAMLGroups   []RoleMapping
+	LDAPGroups   []RoleMapping
+
+	LDAPStrategies []LDAPStrategy
+}
+
+// Load reads a Splunk etc directory, or a tar (optionally gzipped) archive of it, into a Snapshot.
+// `$SPLUNK_HOME` itself is accepted as well.
+func Load(location string) (*Snapshot, error) {
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, fmt.Errorf("offline: %w", err)
+	}
+
+	var files map[string][]byte
+	if info.IsDir() {
+		files, err = readDirectory(location)
+	} else {
+		files, err = readArchive(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("offline: %w", err)
+	}
+
+	snapshot, err := newSnapshot(files)
+	if err != nil {
+		return nil, fmt.Errorf("offline: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// isRelevant reports whether a file, given relative to the etc directory, is needed to build a snapshot.
+func isRelevant(name string) bool {
+	if name == passwdFile {
+		return true
+	}
+
+	switch path.Base(name) {
+	case authorizeConf, authenticationConf, appConf, indexesConf, defaultMeta, localMeta:
+		return strings.HasPrefix(name, "system/") || strings.HasPrefix(name, "apps/")
+	default:
+		return false
+	}
+}
+
+func readDirectory(root string) (map[string][]byte, error) {
+	// accept $SPLUNK_HOME as well as $SPLUNK_HOME/etc
+	if info, err := os.Stat(filepath.Join(root, "etc", "system")); err == nil && info.IsDir() {
+		root = filepath.Join(root, "etc")
+	}
+
+	files := map[string][]byte{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if !isRelevant(name) {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		data, err := readLimited(f, name)
+		if err != nil {
+			return err
+		}
+
+		files[name] = data
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func readArchive(location string) (map[string][]byte, error) {
+	f, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+
+	// gzip streams start with the magic bytes 0x1f 0x8b
+	var archive io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		archive = gz
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(archive)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive %s: %w", location, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := archiveEntryName(header.Name)
+		if !isRelevant(name) {
+			continue
+		}
+
+		data, err := readLimited(tr, name)
+		if err != nil {
+			return nil, err
+		}
+
+		files[name] = data
+	}
+
+	return files, nil
+}
+
+// archiveEntryName returns the path of an archive entry relative to the etc directory.
+func archiveEntryName(name string) string {
+	name = "/" + strings.TrimPrefix(path.Clean("/"+name), "/")
+	if i := strings.Index(name, "/etc/"); i >= 0 {
+		return name[i+len("/etc/"):]
+	}
+
+	return strings.TrimPrefix(name, "/")
+}
+
+func readLimited(r io.Reader, name string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("%s exceeds the maximum supported size", name)
+	}
+
+	return data, nil
+}
+
+// appNames returns the sorted names of apps that have at least one relevant file.
+func appNames(files map[string][]byte) []string {
+	seen := map[string]bool{}
+	for name := range files {
+		parts := strings.SplitN(name, "/", 3)
+		if len(parts) == 3 && parts[0] == "apps" {
+			seen[parts[1]] = true
+		}
+	}
+
+	apps := make([]string, 0, len(seen))
+	for app := range seen {
+		apps = append(apps, app)
+	}
+
+	sort.Strings(apps)
+
+	return apps
+}
+
+// layered merges every copy of a .conf file in the global context, following Splunk's precedence:
+// system/local, then apps/*/local, then apps/*/default, then system/default.
+// Between apps, the one whose name sorts first wins.
+func layered(files map[string][]byte, apps []string, name string) (conf, error) {
+	layers := []string{path.Join("system", "default", name)}
+	for i := len(apps) - 1; i >= 0; i-- {
+		layers = append(layers, path.Join("apps", apps[i], "default", name))
+	}
+	for i := len(apps) - 1; i >= 0; i-- {
+		layers = append(layers, path.Join("apps", apps[i], "local", name))
+	}
+	layers = append(layers, path.Join("system", "local", name))
+
+	merged := conf{}
+	for _, layer := range layers {
+		data, ok := files[layer]
+		if !ok {
+			continue
+		}
+
+		c, err := parseConf(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", layer, err)
+		}
+
+		merged.overlay(c)
+	}
+
+	return merged, nil
+}
+
+// appLayered merges files of a single app, given relative to the app directory in ascending precedence.
+func appLayered(files map[string][]byte, app string, layers ...string) (conf, error) {
+	merged := conf{}
+	for _, layer := range layers {
+		p := path.Join("apps", app, layer)
+
+		data, ok := files[p]
+		if !ok {
+			continue
+		}
+
+		c, err := parseConf(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+
+		merged.overlay(c)
+	}
+
+	return merged, nil
+}
+
+func newSnapshot(files map[string][]byte) (*Snapshot, error) {
+	apps := appNames(files)
+
+	authorize, err := layered(files, apps, authorizeConf)
+	if err != nil {
+		return nil, err
+	}
+
+	authentication, err := layered(files, apps, authenticationConf)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := layered(files, apps, indexesConf)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{}
+	snapshot.Indexes = parseIndexes(indexes)
+	snapshot.Roles = parseRoles(authorize)
+	snapshot.Capabilities = parseCapabilities(authorize, snapshot.Roles)
+	snapshot.SAMLGroups, snapshot.LDAPGroups = parseRoleMappings(authentication)
+	snapshot.LDAPStrategies = parseLDAPStrategies(authentication)
+
+	if data, ok := files[passwdFile]; ok {
+		snapshot.Users = parsePasswd(data)
+	}
+
+	for _, name := range apps {
+		app, err := parseApp(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.Apps = append(snapshot.Apps, *app)
+	}
+
+	return snapshot, nil
+}
+
+// parseRoles reads `[role_<name>]` stanzas. Attributes set to `enabled` are capabilities.
+func parseRoles(authorize conf) []Role {
+	var roles []Role
+	for _, name := range authorize.stanzasWithPrefix(rolePrefix) {
+		attributes := authorize[rolePrefix+name]
+
+		role := Role{
+			Name:                 name,
+			ImportedRoles:        splitList(attributes["importRoles"], ";"),
+			SrchIndexesAllowed:   splitList(attributes["srchIndexesAllowed"], ";"),
+			SrchIndexesDefault:   splitList(attributes["srchIndexesDefault"], ";"),
+			DeleteIndexesAllowed: splitList(attributes["deleteIndexesAllowed"], ";"),
+			SrchFilter:           attributes["srchFilter"],
+		}
+
+		for key, value := range attributes {
+			if value == "enabled" {
+				role.Capabilities = append(role.Capabilities, key)
+			}
+		}
+
+		sort.Strings(role.Capabilities)
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+// parseIndexes reads index stanzas. Stanzas for volumes, virtual index providers and defaults are skipped.
+func parseIndexes(indexes conf) []Index {
+	var rv []Index
+	for _, name := range indexes.stanzasWithPrefix("") {
+		if name == "default" || strings.Contains(name, ":") {
+			continue
+		}
+
+		attributes := indexes[name]
+
+		dataType := attributes["datatype"]
+		if dataType == "" {
+			dataType = "event"
+		}
+
+		rv = append(rv, Index{
+			Name:     name,
+			DataType: dataType,
+			Disabled: attributes["disabled"] == "true" || attributes["disabled"] == "1",
+		})
+	}
+
+	return rv
+}
+
+// parseCapabilities returns every capability declared with a `[capability::<name>]` stanza or assigned to a role.
+func parseCapabilities(authorize conf, roles []Role) []string {
+	seen := map[string]bool{}
+	for _, name := range authorize.stanzasWithPrefix(capabilityPrefix) {
+		seen[name] = true
+	}
+
+	for _, role := range roles {
+		for _, capability := range role.Capabilities {
+			seen[capability] = true
+		}
+	}
+
+	capabilities := make([]string, 0, len(seen))
+	for capability := range seen {
+		capabilities = append(capabilities, capability)
+	}
+
+	sort.Strings(capabilities)
+
+	return capabilities
+}
+
+// parseLDAPStrategies reads the stanzas of LDAP strategies, which are the only ones naming a `host`.
+func parseLDAPStrategies(authentication conf) []LDAPStrategy {
+	var rv []LDAPStrategy
+	for _, name := range authentication.stanzasWithPrefix("") {
+		attributes := authentication[name]
+		if attributes["host"] == "" || strings.HasPrefix(name, roleMapPrefix) {
+			continue
+		}
+
+		rv = append(rv, LDAPStrategy{
+			Name:        name,
+			Host:        attributes["host"],
+			UserBaseDN:  attributes["userBaseDN"],
+			GroupBaseDN: attributes["groupBaseDN"],
+		})
+	}
+
+	return rv
+}
+
+// parseRoleMappings reads `[roleMap_<strategy>]` stanzas, where each attribute maps a role to groups.
+// The `roleMap_SAML` stanza holds SAML mappings, all others belong to LDAP strategies.
+func parseRoleMappings(authentication conf) ([]RoleMapping, []RoleMapping) {
+	var saml, ldap []RoleMapping
+
+	for _, strategy := range authentication.stanzasWithPrefix(roleMapPrefix) {
+		stanzaName := roleMapPrefix + strategy
+
+		groups := map[string][]string{}
+		for role, value := range authentication[stanzaName] {
+			for _, group := range splitList(value, ";") {
+				groups[group] = append(groups[group], role)
+			}
+		}
+
+		names := make([]string, 0, len(groups))
+		for group := range groups {
+			names = append(names, group)
+		}
+
+		sort.Strings(names)
+
+		for _, group := range names {
+			roles := groups[group]
+			sort.Strings(roles)
+
+			mapping := RoleMapping{Strategy: strategy, Group: group, Roles: roles}
+			if stanzaName == samlRoleMap {
+				saml = append(saml, mapping)
+			} else {
+				ldap = append(ldap, mapping)
+			}
+		}
+	}
+
+	return saml, ldap
+}
+
+// parsePasswd reads local users from the passwd file. Each line has the form
+// `:<name>:<hash>::<realname>:<role;role>:<email>:...`.
+func parsePasswd(data []byte) []User {
+	var users []User
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 2 || fields[1] == "" {
+			continue
+		}
+
+		user := User{Name: fields[1]}
+		if len(fields) > 4 {
+			user.RealName = fields[4]
+		}
+		if len(fields) > 5 {
+			user.Roles = splitList(fields[5], ";")
+		}
+		if len(fields) > 6 {
+			user.Email = fields[6]
+		}
+
+		users = append(users, user)
+	}
+
+	return users
+}
+
+// parseApp reads the app.conf and the app-level ACL from the metadata of an app.
+func parseApp(files map[string][]byte, name string) (*App, error) {
+	settings, err := appLayered(files, name, path.Join("default", appConf), path.Join("local", appConf))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := appLayered(files, name, path.Join("metadata", defaultMeta), path.Join("metadata", localMeta))
+	if err != nil {
+		return nil, err
+	}
+
+	app := &App{
+		Name:        name,
+		Label:       settings.stanza("ui")["label"],
+		Description: settings.stanza("launcher")["description"],
+		Version:     settings.stanza("launcher")["version"],
+		Visible:     settings.stanza("ui")["is_visible"] != "false" && settings.stanza("ui")["is_visible"] != "0",
+	}
+
+	// the empty stanza `[]` holds the permissions of the app itself
+	app.Read, app.Write = parseAccess(metadata.stanza("")["access"])
+
+	return app, nil
+}
+
+// parseAccess parses a metadata access attribute such as `read : [ * ], write : [ admin, power ]`.
+func parseAccess(access string) ([]string, []string) {
+	var read, write []string
+
+	for access != "" {
+		open := strings.Index(access, "[")
+		closing := strings.Index(access, "]")
+		if open < 0 || closing < open {
+			break
+		}
+
+		permission := strings.Trim(access[:open], ",: \t")
+		roles := splitList(access[open+1:closing], ",")
+
+		switch permission {
+		case "read":
+			read = roles
+		case "write":
+			write = roles
+		}
+
+		access = access[closing+1:]
+	}
+
+	return read, write
+}
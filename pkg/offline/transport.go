@@ -0,0 +1,472 @@
+package offline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/conductorone/baton-splunk/pkg/splunk"
+)
+
+const (
+	// defaultCount mirrors the page size the management API uses when `count` is not set.
+	defaultCount = 30
+)
+
+// roleFilter matches the `roles="<role>"` search filter used to list users of a role.
+var roleFilter = regexp.MustCompile(`^roles="(.*)"$`)
+
+type entry struct {
+	Name    string                 `json:"name"`
+	ID      string                 `json:"id"`
+	ACL     map[string]interface{} `json:"acl,omitempty"`
+	Content map[string]interface{} `json:"content"`
+}
+
+type paging struct {
+	Total   int `json:"total"`
+	PerPage int `json:"perPage"`
+	Offset  int `json:"offset"`
+}
+
+type listResponse struct {
+	Entries []entry `json:"entry"`
+	Paging  paging  `json:"paging"`
+}
+
+type messagesResponse struct {
+	Messages []splunk.Message `json:"messages"`
+}
+
+// Transport answers read-only Splunk management API requests from a Snapshot,
+// so that splunk.Client can sync an instance from its configuration files.
+type Transport struct {
+	snapshot *Snapshot
+}
+
+func NewTransport(snapshot *Snapshot) *Transport {
+	return &Transport{snapshot: snapshot}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+
+	if req.Method != http.MethodGet {
+		return respond(req, http.StatusMethodNotAllowed, messagesResponse{
+			Messages: []splunk.Message{{Type: "ERROR", Text: "offline snapshots are read-only"}},
+		})
+	}
+
+	collection, name := splitPath(req.URL.Path)
+
+	var entries []entry
+	switch collection {
+	case splunk.UsersBaseURL:
+		entries = t.users(req.URL.Query().Get("search"))
+	case splunk.RolesBaseURL:
+		entries = t.roles()
+	case splunk.CapabilitiesBaseURL:
+		entries = t.capabilities()
+	case splunk.ApplicationsBaseURL:
+		entries = t.apps()
+	case splunk.IndexesBaseURL:
+		entries = t.indexes()
+	case splunk.TokensBaseURL:
+		// tokens are kept in the KV store rather than in configuration files
+		entries = nil
+	case splunk.SavedSearchesBaseURL, splunk.ViewsBaseURL:
+		// knowledge objects aren't part of snapshots
+		entries = nil
+	case splunk.SAMLGroupsBaseURL:
+		entries = t.samlGroups()
+	case splunk.LDAPStrategiesBaseURL:
+		// the groups of a strategy are listed under it
+		if strategy, ok := strings.CutSuffix(name, "/groups"); ok {
+			collection, name = fmt.Sprintf(splunk.LDAPStrategyGroupsBaseURL, url.PathEscape(strategy)), ""
+			entries = t.ldapStrategyGroups(strategy)
+			break
+		}
+
+		entries = t.ldapStrategies()
+	case splunk.LDAPGroupsBaseURL:
+		entries = t.ldapGroups()
+	default:
+		return notFound(req)
+	}
+
+	for i := range entries {
+		entries[i].ID = baseURL(req) + collection + "/" + url.PathEscape(entries[i].Name)
+	}
+
+	if name != "" {
+		for _, e := range entries {
+			if e.Name == name {
+				return respond(req, http.StatusOK, listResponse{
+					Entries: []entry{e},
+					Paging:  paging{Total: 1, PerPage: 1},
+				})
+			}
+		}
+
+		return notFound(req)
+	}
+
+	return respond(req, http.StatusOK, paginate(entries, req.URL.Query()))
+}
+
+// splitPath splits a request path into the collection endpoint and the optional entity name.
+func splitPath(p string) (string, string) {
+	p = strings.TrimSuffix(p, "/")
+
+	for _, collection := range []string{
+		splunk.UsersBaseURL,
+		splunk.RolesBaseURL,
+		splunk.CapabilitiesBaseURL,
+		splunk.ApplicationsBaseURL,
+		splunk.IndexesBaseURL,
+		splunk.TokensBaseURL,
+		splunk.SavedSearchesBaseURL,
+		splunk.ViewsBaseURL,
+		splunk.SAMLGroupsBaseURL,
+		splunk.LDAPStrategiesBaseURL,
+		splunk.LDAPGroupsBaseURL,
+	} {
+		if p == collection {
+			return collection, ""
+		}
+
+		if strings.HasPrefix(p, collection+"/") {
+			name, err := url.PathUnescape(strings.TrimPrefix(p, collection+"/"))
+			if err != nil {
+				return "", ""
+			}
+
+			return collection, name
+		}
+	}
+
+	return "", ""
+}
+
+func baseURL(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host
+}
+
+func paginate(entries []entry, query url.Values) listResponse {
+	count := defaultCount
+	if v, err := strconv.Atoi(query.Get("count")); err == nil && v >= 0 {
+		count = v
+	}
+
+	offset := 0
+	if v, err := strconv.Atoi(query.Get("offset")); err == nil && v > 0 {
+		offset = v
+	}
+
+	total := len(entries)
+	start := offset
+	if start > total {
+		start = total
+	}
+
+	// a count of 0 returns every remaining entry
+	end := total
+	if count > 0 && start+count < total {
+		end = start + count
+	}
+
+	if entries == nil {
+		entries = []entry{}
+	}
+
+	return listResponse{
+		Entries: entries[start:end],
+		Paging:  paging{Total: total, PerPage: count, Offset: offset},
+	}
+}
+
+func (t *Transport) users(search string) []entry {
+	var role string
+	if match := roleFilter.FindStringSubmatch(search); match != nil {
+		role = match[1]
+	}
+
+	capabilities := t.effectiveCapabilities()
+
+	var entries []entry
+	for _, user := range t.snapshot.Users {
+		if role != "" && !contains(user.Roles, role) {
+			continue
+		}
+
+		var userCapabilities []string
+		for _, r := range user.Roles {
+			userCapabilities = append(userCapabilities, capabilities[r]...)
+		}
+
+		entries = append(entries, entry{
+			Name: user.Name,
+			Content: map[string]interface{}{
+				"email":        user.Email,
+				"realname":     user.RealName,
+				"roles":        nonNil(user.Roles),
+				"capabilities": unique(userCapabilities),
+				"type":         splunk.UserTypeSplunk,
+				"locked-out":   false,
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) roles() []entry {
+	byName := t.rolesByName()
+
+	var entries []entry
+	for _, role := range t.snapshot.Roles {
+		var imported []string
+		for _, name := range inheritedRoles(byName, role.Name) {
+			imported = append(imported, byName[name].Capabilities...)
+		}
+
+		entries = append(entries, entry{
+			Name: role.Name,
+			Content: map[string]interface{}{
+				"capabilities":          nonNil(role.Capabilities),
+				"imported_capabilities": unique(imported),
+				"imported_roles":        nonNil(role.ImportedRoles),
+				"srchIndexesAllowed":    nonNil(role.SrchIndexesAllowed),
+				"srchIndexesDefault":    nonNil(role.SrchIndexesDefault),
+				"deleteIndexesAllowed":  nonNil(role.DeleteIndexesAllowed),
+				"srchFilter":            role.SrchFilter,
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) capabilities() []entry {
+	return []entry{{
+		Name: "capabilities",
+		Content: map[string]interface{}{
+			"capabilities": nonNil(t.snapshot.Capabilities),
+		},
+	}}
+}
+
+func (t *Transport) apps() []entry {
+	var entries []entry
+	for _, app := range t.snapshot.Apps {
+		entries = append(entries, entry{
+			Name: app.Name,
+			ACL: map[string]interface{}{
+				"app":     app.Name,
+				"owner":   "nobody",
+				"sharing": "app",
+				"perms": map[string]interface{}{
+					"read":  nonNil(app.Read),
+					"write": nonNil(app.Write),
+				},
+			},
+			Content: map[string]interface{}{
+				"label":       app.Label,
+				"description": app.Description,
+				"version":     app.Version,
+				"visible":     app.Visible,
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) indexes() []entry {
+	var entries []entry
+	for _, index := range t.snapshot.Indexes {
+		entries = append(entries, entry{
+			Name: index.Name,
+			Content: map[string]interface{}{
+				"datatype": index.DataType,
+				"disabled": index.Disabled,
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) samlGroups() []entry {
+	var entries []entry
+	for _, mapping := range t.snapshot.SAMLGroups {
+		entries = append(entries, entry{
+			Name: mapping.Group,
+			Content: map[string]interface{}{
+				"roles": nonNil(mapping.Roles),
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) ldapStrategies() []entry {
+	var entries []entry
+	for _, strategy := range t.snapshot.LDAPStrategies {
+		entries = append(entries, entry{
+			Name: strategy.Name,
+			Content: map[string]interface{}{
+				"host":        strategy.Host,
+				"userBaseDN":  strategy.UserBaseDN,
+				"groupBaseDN": strategy.GroupBaseDN,
+			},
+		})
+	}
+
+	return entries
+}
+
+func (t *Transport) ldapGroups() []entry {
+	var entries []entry
+	for _, mapping := range t.snapshot.LDAPGroups {
+		entries = append(entries, entry{
+			Name: mapping.Strategy + splunk.LDAPGroupSeparator + mapping.Group,
+			Content: map[string]interface{}{
+				"roles":    nonNil(mapping.Roles),
+				"strategy": mapping.Strategy,
+			},
+		})
+	}
+
+	return entries
+}
+
+// ldapStrategyGroups returns the groups of one strategy, named without the strategy as Splunk lists them there.
+func (t *Transport) ldapStrategyGroups(strategy string) []entry {
+	var entries []entry
+	for _, e := range t.ldapGroups() {
+		if s, group, _ := strings.Cut(e.Name, splunk.LDAPGroupSeparator); s == strategy {
+			e.Name = group
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+func (t *Transport) rolesByName() map[string]Role {
+	byName := make(map[string]Role, len(t.snapshot.Roles))
+	for _, role := range t.snapshot.Roles {
+		byName[role.Name] = role
+	}
+
+	return byName
+}
+
+// effectiveCapabilities returns the capabilities of every role, including the ones of imported roles.
+func (t *Transport) effectiveCapabilities() map[string][]string {
+	byName := t.rolesByName()
+
+	capabilities := make(map[string][]string, len(byName))
+	for name, role := range byName {
+		all := append([]string(nil), role.Capabilities...)
+		for _, imported := range inheritedRoles(byName, name) {
+			all = append(all, byName[imported].Capabilities...)
+		}
+
+		capabilities[name] = unique(all)
+	}
+
+	return capabilities
+}
+
+// inheritedRoles returns every role imported by the given role, directly or transitively.
+func inheritedRoles(byName map[string]Role, name string) []string {
+	visited := map[string]bool{name: true}
+	queue := append([]string(nil), byName[name].ImportedRoles...)
+
+	var inherited []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if visited[current] {
+			continue
+		}
+
+		visited[current] = true
+		inherited = append(inherited, current)
+		queue = append(queue, byName[current].ImportedRoles...)
+	}
+
+	return inherited
+}
+
+func respond(req *http.Request, statusCode int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("offline: failed to encode response: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+func notFound(req *http.Request) (*http.Response, error) {
+	return respond(req, http.StatusNotFound, messagesResponse{
+		Messages: []splunk.Message{{Type: "ERROR", Text: fmt.Sprintf("%s is not available in offline snapshots", req.URL.Path)}},
+	})
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	rv := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			rv = append(rv, v)
+		}
+	}
+
+	sort.Strings(rv)
+
+	return rv
+}
+
+// nonNil makes sure empty lists are encoded as `[]` like the management API does.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
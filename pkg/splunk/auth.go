@@ -0,0 +1,246 @@
+package splunk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Authenticator provides the Authorization header sent with every request to the management API.
+type Authenticator interface {
+	// Authorization returns the header value for requests to the deployment of the client.
+	Authorization(ctx context.Context, c *Client) (string, error)
+	// Expire discards a header value Splunk rejected, and reports whether a new one can be obtained.
+	Expire(c *Client, authorization string) bool
+}
+
+// StaticAuth sends the same header on every request, such as a bearer token or basic credentials.
+type StaticAuth string
+
+func (a StaticAuth) Authorization(_ context.Context, _ *Client) (string, error) {
+	return string(a), nil
+}
+
+func (a StaticAuth) Expire(_ *Client, _ string) bool {
+	return false
+}
+
+// Secret provides a credential. Secrets read from files are read again on every use,
+// so secrets rotated on disk are picked up without a restart.
+type Secret interface {
+	Value() (string, error)
+}
+
+// StaticSecret is a credential known upfront, such as one passed in a flag.
+type StaticSecret string
+
+func (s StaticSecret) Value() (string, error) {
+	return string(s), nil
+}
+
+// FileSecret is the path of a file holding a credential, such as a mounted Kubernetes secret.
+type FileSecret string
+
+func (s FileSecret) Value() (string, error) {
+	content, err := os.ReadFile(string(s))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	// secret files usually end with a newline
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", string(s))
+	}
+
+	return secret, nil
+}
+
+// TokenAuth sends a bearer token, read from its secret on every request.
+type TokenAuth struct {
+	token Secret
+}
+
+func NewTokenAuth(token Secret) *TokenAuth {
+	return &TokenAuth{
+		token: token,
+	}
+}
+
+func (a *TokenAuth) Authorization(_ context.Context, _ *Client) (string, error) {
+	token, err := a.token.Value()
+	if err != nil {
+		return "", err
+	}
+
+	return "Bearer " + token, nil
+}
+
+// Expire reports whether the token changed since it was rejected, so that a token rotated
+// in the meantime is tried right away.
+func (a *TokenAuth) Expire(c *Client, authorization string) bool {
+	current, err := a.Authorization(context.Background(), c)
+
+	return err == nil && current != authorization
+}
+
+// BasicAuth sends a username and password on every request, reading the password from its secret each time.
+type BasicAuth struct {
+	username string
+	password Secret
+}
+
+func NewBasicAuth(username string, password Secret) *BasicAuth {
+	return &BasicAuth{
+		username: username,
+		password: password,
+	}
+}
+
+func (a *BasicAuth) Authorization(_ context.Context, _ *Client) (string, error) {
+	password, err := a.password.Value()
+	if err != nil {
+		return "", err
+	}
+
+	credentials := fmt.Sprintf("%s:%s", a.username, password)
+
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)), nil
+}
+
+// Expire reports whether the password changed since it was rejected.
+func (a *BasicAuth) Expire(c *Client, authorization string) bool {
+	current, err := a.Authorization(context.Background(), c)
+
+	return err == nil && current != authorization
+}
+
+// SessionAuth exchanges a username and password for a session key on each instance,
+// so the password is only sent when logging in. Expired sessions are replaced by logging in again,
+// with the password read from its secret at that time. Keys are kept per base URL rather than per
+// deployment, since writes to a search head cluster member are sent to its captain. Concurrent requests
+// to an instance without a key share a single login, and the lock is only held to read or replace keys.
+type SessionAuth struct {
+	username string
+	password Secret
+
+	mtx    sync.Mutex
+	keys   map[string]string
+	logins singleflight.Group
+}
+
+func NewSessionAuth(username string, password Secret) *SessionAuth {
+	return &SessionAuth{
+		username: username,
+		password: password,
+		keys:     make(map[string]string),
+	}
+}
+
+func (a *SessionAuth) Authorization(ctx context.Context, c *Client) (string, error) {
+	baseURL := c.CreateUrl("")
+
+	if authorization, ok := a.key(baseURL); ok {
+		return authorization, nil
+	}
+
+	authorization, err, _ := a.logins.Do(baseURL, func() (interface{}, error) {
+		// a login that finished while this one was being started already stored a key
+		if authorization, ok := a.key(baseURL); ok {
+			return authorization, nil
+		}
+
+		password, err := a.password.Value()
+		if err != nil {
+			return "", err
+		}
+
+		sessionKey, err := c.Login(ctx, a.username, password)
+		if err != nil {
+			return "", fmt.Errorf("failed to log in to %s: %w", c.Deployment(), err)
+		}
+
+		authorization := "Splunk " + sessionKey
+
+		a.mtx.Lock()
+		a.keys[baseURL] = authorization
+		a.mtx.Unlock()
+
+		return authorization, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return authorization.(string), nil
+}
+
+func (a *SessionAuth) key(baseURL string) (string, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	authorization, ok := a.keys[baseURL]
+
+	return authorization, ok
+}
+
+func (a *SessionAuth) Expire(c *Client, authorization string) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	// another request may have logged in again already
+	if a.keys[c.CreateUrl("")] == authorization {
+		delete(a.keys, c.CreateUrl(""))
+	}
+
+	return true
+}
+
+type loginResponse struct {
+	SessionKey string `json:"sessionKey"`
+}
+
+// Login exchanges a username and password for a session key on the deployment of the client.
+func (c *Client) Login(ctx context.Context, username string, password string) (string, error) {
+	data := url.Values{}
+
+	data.Set(UsernameField, username)
+	data.Set(PasswordField, password)
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.CreateUrl(LoginURL), data, nil, "")
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+	rawResponse, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer rawResponse.Body.Close()
+
+	if rawResponse.StatusCode >= 300 {
+		return "", newAPIError(rawResponse)
+	}
+
+	var response loginResponse
+	if err := json.NewDecoder(rawResponse.Body).Decode(&response); err != nil {
+		return "", err
+	}
+
+	if response.SessionKey == "" {
+		return "", fmt.Errorf("no session key returned")
+	}
+
+	return response.SessionKey, nil
+}
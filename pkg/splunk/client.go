@@ -8,9 +8,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"time"
 )
 
 const (
@@ -18,6 +16,8 @@ const (
 	BaseURL      = "https://%s:8089"
 	CloudBaseURL = "https://%s.splunkcloud.com:8089"
 
+	LoginURL = "/services/auth/login"
+
 	UsersBaseURL        = "/services/authentication/users"
 	UserBaseURL         = "/services/authentication/users/%s"
 	RolesBaseURL        = "/services/authorization/roles"
@@ -25,16 +25,109 @@ const (
 	CapabilitiesBaseURL = "/services/authorization/grantable_capabilities/capabilities"
 	ApplicationsBaseURL = "/services/apps/local"
 	ApplicationBaseURL  = "/services/apps/local/%s"
-
-	RolesField        = "roles"
-	CapabilitiesField = "capabilities"
+	IndexesBaseURL      = "/services/data/indexes"
+	TokensBaseURL       = "/services/authorization/tokens"
+	TokenBaseURL        = "/services/authorization/tokens/%s"
+
+	// Knowledge objects are listed across all owners and apps, and addressed in the namespace of their owner and app.
+	SavedSearchesBaseURL = "/servicesNS/-/-/saved/searches"
+	SavedSearchBaseURL   = "/servicesNS/%s/%s/saved/searches/%s"
+	SavedSearchACLURL    = "/servicesNS/%s/%s/saved/searches/%s/acl"
+	ViewsBaseURL         = "/servicesNS/-/-/data/ui/views"
+	ViewBaseURL          = "/servicesNS/%s/%s/data/ui/views/%s"
+	ViewACLURL           = "/servicesNS/%s/%s/data/ui/views/%s/acl"
+
+	// SAML groups map groups asserted by the identity provider to Splunk roles.
+	SAMLGroupsBaseURL = "/services/admin/SAML-groups"
+	SAMLGroupBaseURL  = "/services/admin/SAML-groups/%s"
+
+	// LDAP strategies are the LDAP servers Splunk authenticates against. LDAP groups are
+	// named `<strategy>,<group>` and map the groups of a strategy to Splunk roles.
+	LDAPStrategiesBaseURL     = "/services/authentication/providers/LDAP"
+	LDAPStrategyGroupsBaseURL = "/services/authentication/providers/LDAP/%s/groups"
+	LDAPGroupsBaseURL         = "/services/admin/LDAP-groups"
+	LDAPGroupBaseURL          = "/services/admin/LDAP-groups/%s"
+
+	// LDAPGroupSeparator separates the strategy from the group in the name of an LDAP group.
+	LDAPGroupSeparator = ","
+
+	// Search head cluster and indexer cluster endpoints used to discover the nodes of a deployment.
+	// Cluster managers older than Splunk 9.0 only answer on the `master` endpoint.
+	SHClusterConfigURL     = "/services/shcluster/config"
+	SHClusterMembersURL    = "/services/shcluster/member/members"
+	SHClusterCaptainURL    = "/services/shcluster/captain/info"
+	ClusterConfigURL       = "/services/cluster/config"
+	ClusterManagerPeersURL = "/services/cluster/manager/peers"
+	ClusterMasterPeersURL  = "/services/cluster/master/peers"
+
+	// LockoutsBaseURL is a custom conf file holding the roles of users locked by the connector, so they can be restored.
+	LockoutsBaseURL = "/servicesNS/nobody/system/configs/conf-baton_lockout"
+	LockoutBaseURL  = "/servicesNS/nobody/system/configs/conf-baton_lockout/%s"
+
+	LockoutRolesSeparator = ";"
+
+	NameField                = "name"
+	UsernameField            = "username"
+	PasswordField            = "password"
+	EmailField               = "email"
+	RealNameField            = "realname"
+	ForceChangePasswordField = "force-change-pass"
+	LockedOutField           = "locked-out"
+
+	IDField     = "id"
+	StatusField = "status"
+
+	OwnerField   = "owner"
+	SharingField = "sharing"
+
+	// ACL fields knowledge objects can be filtered on.
+	ACLAppFilter   = "eai:acl.app"
+	ACLOwnerFilter = "eai:acl.owner"
+
+	TokenStatusEnabled  = "enabled"
+	TokenStatusDisabled = "disabled"
+
+	SharingUser   = "user"
+	SharingApp    = "app"
+	SharingGlobal = "global"
+
+	// NobodyOwner owns knowledge objects that don't belong to any user.
+	NobodyOwner = "nobody"
+	// AnyOwner addresses knowledge objects whoever owns them.
+	AnyOwner = "-"
+
+	// Authentication sources reported in the `type` of a user.
+	UserTypeSplunk = "Splunk"
+	UserTypeLDAP   = "LDAP"
+	UserTypeSAML   = "SAML"
+
+	// Indexer clustering modes reported in the cluster configuration of an instance.
+	ClusterModeDisabled = "disabled"
+	ClusterModeManager  = "manager"
+	ClusterModeMaster   = "master"
+
+	RolesField         = "roles"
+	CapabilitiesField  = "capabilities"
+	ImportedRolesField = "imported_roles"
+
+	SrchIndexesAllowedField   = "srchIndexesAllowed"
+	SrchIndexesDefaultField   = "srchIndexesDefault"
+	DeleteIndexesAllowedField = "deleteIndexesAllowed"
+	SrchFilterField           = "srchFilter"
 )
 
+// Client talks to the management API of a single Splunk deployment. The target
+// deployment is fixed at construction so a client can be shared between goroutines.
 type Client struct {
 	httpClient *http.Client
-	Auth       string
-	Cloud      bool
-	Deployment string
+	auth       Authenticator
+	cloud      bool
+	deployment string
+	baseURL    string
+
+	maxRetries   int
+	retryBackoff time.Duration
+	requestSlots chan struct{}
 }
 
 type PaginationData struct {
@@ -53,38 +146,73 @@ type Response[T any] struct {
 	PaginationData `json:"paging"`
 }
 
-func NewClient(httpClient *http.Client, auth string, cloud bool) *Client {
-	return &Client{
+func NewClient(httpClient *http.Client, auth Authenticator, cloud bool, deployment string, opts ...ClientOption) *Client {
+	c := &Client{
 		httpClient: httpClient,
-		Auth:       auth,
-		Cloud:      cloud,
-		Deployment: Localhost,
+		auth:       auth,
+		cloud:      cloud,
+		deployment: deployment,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
-func (c *Client) PointToDeployment(deployment string) {
-	c.Deployment = deployment
+// WithBaseURL sends requests to the given scheme, host and port instead of the
+// address derived from the deployment name.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
 }
 
-func (c *Client) PointToLocalhost() {
-	c.Deployment = Localhost
+// ParseDeployment splits a deployment given as a full URL, such as `https://splunk.corp:8443`,
+// into the deployment name, its host and port, and the base URL of its management API.
+// Deployments given by name or address keep the default base URL, returned empty.
+func ParseDeployment(deployment string) (string, string, error) {
+	if !strings.Contains(deployment, "://") {
+		return deployment, "", nil
+	}
+
+	u, err := url.Parse(deployment)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid deployment URL %s: %w", deployment, err)
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", "", fmt.Errorf("deployment URL %s must use https or http", deployment)
+	}
+
+	if u.Host == "" {
+		return "", "", fmt.Errorf("deployment URL %s is missing a host", deployment)
+	}
+
+	return u.Host, u.Scheme + "://" + u.Host + strings.TrimSuffix(u.Path, "/"), nil
 }
 
-func (c *Client) ResetPointer() {
-	c.Deployment = ""
+// Deployment returns the name or address of the deployment the client is bound to.
+func (c *Client) Deployment() string {
+	return c.deployment
 }
 
-// GetUrl returns the full URL for the given endpoint based on platform.
+// CreateUrl returns the full URL for the given endpoint based on platform.
 func (c *Client) CreateUrl(endpoint string) string {
-	if c.Cloud {
-		return fmt.Sprintf(CloudBaseURL, c.Deployment) + endpoint
+	if c.baseURL != "" {
+		return c.baseURL + endpoint
+	}
+
+	if c.cloud {
+		return fmt.Sprintf(CloudBaseURL, c.deployment) + endpoint
 	} else {
-		return fmt.Sprintf(BaseURL, c.Deployment) + endpoint
+		return fmt.Sprintf(BaseURL, c.deployment) + endpoint
 	}
 }
 
 func (c *Client) IsCloudPlatform() bool {
-	return c.Cloud
+	return c.cloud
 }
 
 // GetUsers returns all users under specific Splunk instance.
@@ -225,6 +353,25 @@ func (c *Client) GetApplication(ctx context.Context, applicationName string) (*A
 	return &applicationResponse.Values[0], nil
 }
 
+// GetIndexes returns all indexes under specific Splunk instance.
+func (c *Client) GetIndexes(ctx context.Context, getIndexesVars PaginationVars) ([]Index, string, error) {
+	var indexesResponse Response[Index]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(IndexesBaseURL),
+		&indexesResponse,
+		&getIndexesVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&indexesResponse)
+}
+
 // GetCapabilities returns all grantable capabilities under specific Splunk instance.
 func (c *Client) GetCapabilities(ctx context.Context, getCapabilitiesVars PaginationVars) ([]Capability, string, error) {
 	var capabilitiesResponse Response[Capability]
@@ -244,6 +391,564 @@ func (c *Client) GetCapabilities(ctx context.Context, getCapabilitiesVars Pagina
 	return handlePagination(&capabilitiesResponse)
 }
 
+// GetTokens returns all authentication tokens under specific Splunk instance.
+func (c *Client) GetTokens(ctx context.Context, getTokensVars PaginationVars) ([]Token, string, error) {
+	var tokensResponse Response[Token]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(TokensBaseURL),
+		&tokensResponse,
+		&getTokensVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&tokensResponse)
+}
+
+// GetToken returns a specific authentication token under Splunk instance.
+func (c *Client) GetToken(ctx context.Context, tokenId string) (*Token, error) {
+	var tokensResponse Response[Token]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(TokensBaseURL),
+		&tokensResponse,
+		nil,
+		fmt.Sprintf("%s=%s", NameField, tokenId),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the search filter may match more than the requested token
+	for _, token := range tokensResponse.Values {
+		if token.Name == tokenId {
+			tokenCopy := token
+			return &tokenCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("token %s not found", tokenId)
+}
+
+// UpdateTokenStatus enables or disables a specific token of a user under Splunk instance.
+func (c *Client) UpdateTokenStatus(ctx context.Context, owner string, tokenId string, tokenStatus string) error {
+	data := url.Values{}
+
+	data.Set(IDField, tokenId)
+	data.Set(StatusField, tokenStatus)
+
+	return c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(TokenBaseURL, owner)),
+		data,
+		"",
+	)
+}
+
+// DeleteToken removes a specific token of a user from Splunk instance.
+func (c *Client) DeleteToken(ctx context.Context, owner string, tokenId string) error {
+	query := url.Values{}
+	query.Set(IDField, tokenId)
+
+	return c.delete(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(TokenBaseURL, owner))+"?"+query.Encode(),
+	)
+}
+
+// GetSavedSearches returns the saved searches and alerts of a specific app under Splunk instance.
+func (c *Client) GetSavedSearches(ctx context.Context, app string, getSavedSearchesVars PaginationVars) ([]KnowledgeObject, string, error) {
+	return c.getKnowledgeObjects(ctx, SavedSearchesBaseURL, ACLAppFilter, app, getSavedSearchesVars)
+}
+
+// GetSavedSearchesByOwner returns the saved searches and alerts owned by a specific user, across all apps.
+func (c *Client) GetSavedSearchesByOwner(ctx context.Context, owner string, getSavedSearchesVars PaginationVars) ([]KnowledgeObject, string, error) {
+	return c.getKnowledgeObjects(ctx, SavedSearchesBaseURL, ACLOwnerFilter, owner, getSavedSearchesVars)
+}
+
+// UpdateSavedSearchOwner hands a saved search or alert over to another user, keeping its sharing level.
+func (c *Client) UpdateSavedSearchOwner(ctx context.Context, object *KnowledgeObject, owner string) error {
+	return c.updateKnowledgeObjectOwner(ctx, SavedSearchACLURL, object, owner)
+}
+
+// GetSavedSearch returns a specific saved search or alert of an app, shared at the given level, whoever owns it.
+func (c *Client) GetSavedSearch(ctx context.Context, app string, sharing string, name string) (*KnowledgeObject, error) {
+	return c.getKnowledgeObject(ctx, SavedSearchBaseURL, app, sharing, name)
+}
+
+// GetViews returns the views, such as dashboards, of a specific app under Splunk instance.
+func (c *Client) GetViews(ctx context.Context, app string, getViewsVars PaginationVars) ([]KnowledgeObject, string, error) {
+	return c.getKnowledgeObjects(ctx, ViewsBaseURL, ACLAppFilter, app, getViewsVars)
+}
+
+// GetViewsByOwner returns the views owned by a specific user, across all apps.
+func (c *Client) GetViewsByOwner(ctx context.Context, owner string, getViewsVars PaginationVars) ([]KnowledgeObject, string, error) {
+	return c.getKnowledgeObjects(ctx, ViewsBaseURL, ACLOwnerFilter, owner, getViewsVars)
+}
+
+// UpdateViewOwner hands a view over to another user, keeping its sharing level.
+func (c *Client) UpdateViewOwner(ctx context.Context, object *KnowledgeObject, owner string) error {
+	return c.updateKnowledgeObjectOwner(ctx, ViewACLURL, object, owner)
+}
+
+// GetView returns a specific view of an app, shared at the given level, whoever owns it.
+func (c *Client) GetView(ctx context.Context, app string, sharing string, name string) (*KnowledgeObject, error) {
+	return c.getKnowledgeObject(ctx, ViewBaseURL, app, sharing, name)
+}
+
+func (c *Client) getKnowledgeObjects(
+	ctx context.Context,
+	endpoint string,
+	aclFilter string,
+	value string,
+	paginationVars PaginationVars,
+) ([]KnowledgeObject, string, error) {
+	var knowledgeObjectsResponse Response[KnowledgeObject]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(endpoint),
+		&knowledgeObjectsResponse,
+		&paginationVars,
+		fmt.Sprintf("%s=\"%s\"", aclFilter, value),
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	objects, nextPage, err := handlePagination(&knowledgeObjectsResponse)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// the search filter may match values sharing a prefix with the requested one
+	rv := make([]KnowledgeObject, 0, len(objects))
+	for _, object := range objects {
+		if (aclFilter == ACLAppFilter && object.ACL.App == value) || (aclFilter == ACLOwnerFilter && object.ACL.Owner == value) {
+			rv = append(rv, object)
+		}
+	}
+
+	return rv, nextPage, nil
+}
+
+func (c *Client) updateKnowledgeObjectOwner(ctx context.Context, endpoint string, object *KnowledgeObject, owner string) error {
+	data := url.Values{}
+
+	data.Set(OwnerField, owner)
+	data.Set(SharingField, object.ACL.Sharing)
+
+	return c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(endpoint, url.PathEscape(object.ACL.Owner), url.PathEscape(object.ACL.App), url.PathEscape(object.Name))),
+		data,
+		"",
+	)
+}
+
+func (c *Client) getKnowledgeObject(ctx context.Context, endpoint string, app string, sharing string, name string) (*KnowledgeObject, error) {
+	var knowledgeObjectResponse Response[KnowledgeObject]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(endpoint, AnyOwner, url.PathEscape(app), url.PathEscape(name))),
+		&knowledgeObjectResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// objects of other apps shared globally are visible from the app too
+	for _, object := range knowledgeObjectResponse.Values {
+		if object.Name == name && object.ACL.App == app && object.ACL.Sharing == sharing {
+			objectCopy := object
+			return &objectCopy, nil
+		}
+	}
+
+	return nil, fmt.Errorf("knowledge object %s not found", name)
+}
+
+// CreateUserParams holds the attributes of a new local Splunk user.
+type CreateUserParams struct {
+	Name                string
+	Password            string
+	Email               string
+	RealName            string
+	Roles               []string
+	ForceChangePassword bool
+}
+
+// CreateUser creates a new local user under Splunk instance.
+func (c *Client) CreateUser(ctx context.Context, params CreateUserParams) (*User, error) {
+	var userResponse Response[User]
+
+	data := url.Values{}
+
+	data.Set(NameField, params.Name)
+	data.Set(PasswordField, params.Password)
+	data.Set(ForceChangePasswordField, strconv.FormatBool(params.ForceChangePassword))
+
+	if params.Email != "" {
+		data.Set(EmailField, params.Email)
+	}
+
+	if params.RealName != "" {
+		data.Set(RealNameField, params.RealName)
+	}
+
+	for _, role := range params.Roles {
+		data.Add(RolesField, role)
+	}
+
+	err := c.doRequest(
+		ctx,
+		http.MethodPost,
+		c.CreateUrl(UsersBaseURL),
+		data,
+		&userResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(userResponse.Values) == 0 {
+		return nil, fmt.Errorf("user %s was not returned after creation", params.Name)
+	}
+
+	return &userResponse.Values[0], nil
+}
+
+// DeleteUser removes a specific user from Splunk instance.
+func (c *Client) DeleteUser(ctx context.Context, userId string) error {
+	return c.delete(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(UserBaseURL, userId)),
+	)
+}
+
+// UnlockUser clears the locked-out state Splunk sets after too many failed logins.
+func (c *Client) UnlockUser(ctx context.Context, userId string) error {
+	data := url.Values{}
+
+	data.Set(LockedOutField, "0")
+
+	return c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(UserBaseURL, userId)),
+		data,
+		"",
+	)
+}
+
+// GetLockouts returns the roles saved for every user locked by the connector.
+func (c *Client) GetLockouts(ctx context.Context, getLockoutsVars PaginationVars) ([]Lockout, string, error) {
+	var lockoutsResponse Response[Lockout]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(LockoutsBaseURL),
+		&lockoutsResponse,
+		&getLockoutsVars,
+		"",
+	)
+
+	if err != nil {
+		// the conf file doesn't exist until a user is locked
+		if isNotFound(err) {
+			return nil, "", nil
+		}
+
+		return nil, "", err
+	}
+
+	return handlePagination(&lockoutsResponse)
+}
+
+// GetLockout returns the roles saved when a specific user was locked by the connector,
+// or nil if the user isn't locked.
+func (c *Client) GetLockout(ctx context.Context, userId string) (*Lockout, error) {
+	var lockoutResponse Response[Lockout]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(LockoutBaseURL, userId)),
+		&lockoutResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		// the conf file or stanza doesn't exist until a user is locked
+		if isNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	for _, lockout := range lockoutResponse.Values {
+		if lockout.Name == userId {
+			lockoutCopy := lockout
+			return &lockoutCopy, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// CreateLockout saves the roles of a specific user before the connector locks them.
+func (c *Client) CreateLockout(ctx context.Context, userId string, roles []string) error {
+	data := url.Values{}
+
+	data.Set(NameField, userId)
+	data.Set(RolesField, strings.Join(roles, LockoutRolesSeparator))
+
+	return c.post(
+		ctx,
+		c.CreateUrl(LockoutsBaseURL),
+		data,
+		"",
+	)
+}
+
+// DeleteLockout removes the saved roles of a specific user once they are restored.
+func (c *Client) DeleteLockout(ctx context.Context, userId string) error {
+	return c.delete(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(LockoutBaseURL, userId)),
+	)
+}
+
+// GetSAMLGroups returns the SAML group to role mappings under specific Splunk instance.
+func (c *Client) GetSAMLGroups(ctx context.Context, getSAMLGroupsVars PaginationVars) ([]SAMLGroup, string, error) {
+	var samlGroupsResponse Response[SAMLGroup]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(SAMLGroupsBaseURL),
+		&samlGroupsResponse,
+		&getSAMLGroupsVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&samlGroupsResponse)
+}
+
+// GetSAMLGroup returns the roles mapped to one specific SAML group under Splunk instance.
+func (c *Client) GetSAMLGroup(ctx context.Context, groupName string) (*SAMLGroup, error) {
+	var samlGroupResponse Response[SAMLGroup]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(SAMLGroupBaseURL, url.PathEscape(groupName))),
+		&samlGroupResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(samlGroupResponse.Values) == 0 {
+		return nil, fmt.Errorf("SAML group %s not found", groupName)
+	}
+
+	return &samlGroupResponse.Values[0], nil
+}
+
+// UpdateSAMLGroupRoles replaces the roles mapped to a specific SAML group under Splunk instance.
+// Splunk doesn't keep mappings without roles, use DeleteSAMLGroup to remove the last one.
+func (c *Client) UpdateSAMLGroupRoles(ctx context.Context, groupName string, roles []string) error {
+	data := url.Values{}
+
+	for _, role := range roles {
+		data.Add(RolesField, role)
+	}
+
+	return c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(SAMLGroupBaseURL, url.PathEscape(groupName))),
+		data,
+		"",
+	)
+}
+
+// DeleteSAMLGroup removes the role mapping of a specific SAML group from Splunk instance.
+func (c *Client) DeleteSAMLGroup(ctx context.Context, groupName string) error {
+	return c.delete(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(SAMLGroupBaseURL, url.PathEscape(groupName))),
+	)
+}
+
+// GetLDAPStrategies returns the LDAP strategies configured under specific Splunk instance.
+func (c *Client) GetLDAPStrategies(ctx context.Context, getLDAPStrategiesVars PaginationVars) ([]LDAPStrategy, string, error) {
+	var ldapStrategiesResponse Response[LDAPStrategy]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(LDAPStrategiesBaseURL),
+		&ldapStrategiesResponse,
+		&getLDAPStrategiesVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&ldapStrategiesResponse)
+}
+
+// GetLDAPStrategyGroups returns the LDAP groups of one specific strategy, along with their members and mapped roles.
+// Groups are named `<strategy>,<group>`, like the ones listed across all strategies.
+func (c *Client) GetLDAPStrategyGroups(ctx context.Context, strategy string, getLDAPGroupsVars PaginationVars) ([]LDAPGroup, string, error) {
+	var ldapGroupsResponse Response[LDAPGroup]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(LDAPStrategyGroupsBaseURL, url.PathEscape(strategy))),
+		&ldapGroupsResponse,
+		&getLDAPGroupsVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	for i := range ldapGroupsResponse.Values {
+		ldapGroupsResponse.Values[i].Name = strategy + LDAPGroupSeparator + ldapGroupsResponse.Values[i].Name
+	}
+
+	return handlePagination(&ldapGroupsResponse)
+}
+
+// GetLDAPGroup returns one specific LDAP group, named `<strategy>,<group>`, under Splunk instance.
+func (c *Client) GetLDAPGroup(ctx context.Context, groupName string) (*LDAPGroup, error) {
+	var ldapGroupResponse Response[LDAPGroup]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(LDAPGroupBaseURL, url.PathEscape(groupName))),
+		&ldapGroupResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ldapGroupResponse.Values) == 0 {
+		return nil, fmt.Errorf("LDAP group %s not found", groupName)
+	}
+
+	return &ldapGroupResponse.Values[0], nil
+}
+
+// UpdateLDAPGroupRoles replaces the roles mapped to a specific LDAP group under Splunk instance.
+func (c *Client) UpdateLDAPGroupRoles(ctx context.Context, groupName string, roles []string) error {
+	data := url.Values{}
+
+	data.Set(RolesField, "")
+
+	for _, role := range roles {
+		data.Add(RolesField, role)
+	}
+
+	return c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(LDAPGroupBaseURL, url.PathEscape(groupName))),
+		data,
+		"",
+	)
+}
+
+// CreateRoleParams holds the attributes of a new Splunk role.
+type CreateRoleParams struct {
+	Name                 string
+	ImportedRoles        []string
+	Capabilities         []string
+	SrchIndexesAllowed   []string
+	SrchIndexesDefault   []string
+	DeleteIndexesAllowed []string
+	SrchFilter           string
+}
+
+// CreateRole creates a new role under Splunk instance.
+func (c *Client) CreateRole(ctx context.Context, params CreateRoleParams) (*Role, error) {
+	var roleResponse Response[Role]
+
+	data := url.Values{}
+
+	data.Set(NameField, params.Name)
+
+	if params.SrchFilter != "" {
+		data.Set(SrchFilterField, params.SrchFilter)
+	}
+
+	for field, values := range map[string][]string{
+		ImportedRolesField:        params.ImportedRoles,
+		CapabilitiesField:         params.Capabilities,
+		SrchIndexesAllowedField:   params.SrchIndexesAllowed,
+		SrchIndexesDefaultField:   params.SrchIndexesDefault,
+		DeleteIndexesAllowedField: params.DeleteIndexesAllowed,
+	} {
+		for _, value := range values {
+			data.Add(field, value)
+		}
+	}
+
+	err := c.doRequest(
+		ctx,
+		http.MethodPost,
+		c.CreateUrl(RolesBaseURL),
+		data,
+		&roleResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(roleResponse.Values) == 0 {
+		return nil, fmt.Errorf("role %s was not returned after creation", params.Name)
+	}
+
+	return &roleResponse.Values[0], nil
+}
+
+// DeleteRole removes a specific role from Splunk instance.
+func (c *Client) DeleteRole(ctx context.Context, roleId string) error {
+	return c.delete(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(RoleBaseURL, roleId)),
+	)
+}
+
 // UpdateUserRoles updates roles of a specific user under Splunk instance.
 func (c *Client) UpdateUserRoles(ctx context.Context, userId string, roles []string) error {
 	data := url.Values{}
@@ -292,15 +997,65 @@ func (c *Client) UpdateRoleCapabilities(ctx context.Context, roleId string, capa
 	return nil
 }
 
+// UpdateRoleImportedRoles updates the roles a specific role inherits from under Splunk instance.
+func (c *Client) UpdateRoleImportedRoles(ctx context.Context, roleId string, importedRoles []string) error {
+	data := url.Values{}
+
+	data.Set(ImportedRolesField, "")
+
+	for _, r := range importedRoles {
+		data.Add(ImportedRolesField, r)
+	}
+
+	err := c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(RoleBaseURL, roleId)),
+		data,
+		"",
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateRoleIndexes updates one of the index lists (`srchIndexesAllowed`, `srchIndexesDefault`
+// or `deleteIndexesAllowed`) of a specific role under Splunk instance.
+func (c *Client) UpdateRoleIndexes(ctx context.Context, roleId string, field string, indexes []string) error {
+	data := url.Values{}
+
+	data.Set(field, "")
+
+	for _, i := range indexes {
+		data.Add(field, i)
+	}
+
+	err := c.post(
+		ctx,
+		c.CreateUrl(fmt.Sprintf(RoleBaseURL, roleId)),
+		data,
+		"",
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Handles pagination for Splunk API
-// `offset` is 0-indexed representation of the current page,
+// `offset` is the 0-indexed position of the first item in the response,
 // `perPage` is the number of items per page and
 // `total` is the total number of items in the response.
+// The next page starts right after the last returned item.
 func handlePagination[T any](response *Response[T]) ([]T, string, error) {
-	total, perPage, offset := response.Total, response.PerPage, response.Offset+1
+	total, next := response.Total, response.Offset+len(response.Values)
 
-	if (offset * perPage) < total {
-		return response.Values, strconv.Itoa(offset), nil
+	if len(response.Values) > 0 && next < total {
+		return response.Values, strconv.Itoa(next), nil
 	}
 
 	return response.Values, "", nil
@@ -353,6 +1108,13 @@ func (c *Client) post(
 	return c.doRequest(ctx, http.MethodPost, urlAddress, data, nil, nil, filter)
 }
 
+func (c *Client) delete(
+	ctx context.Context,
+	urlAddress string,
+) error {
+	return c.doRequest(ctx, http.MethodDelete, urlAddress, nil, nil, nil, "")
+}
+
 func (c *Client) doRequest(
 	ctx context.Context,
 	method string,
@@ -362,6 +1124,99 @@ func (c *Client) doRequest(
 	paginationVars *PaginationVars,
 	filter string,
 ) error {
+	rawResponse, err := c.send(ctx, method, urlAddress, data, paginationVars, filter)
+	if err != nil {
+		return err
+	}
+
+	defer rawResponse.Body.Close()
+
+	if rawResponse.StatusCode >= 300 {
+		return newAPIError(rawResponse)
+	}
+
+	// the caller isn't interested in the response body
+	if resourceResponse == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(rawResponse.Body).Decode(&resourceResponse); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// send performs an authorized request. If Splunk rejects the credentials and the
+// authenticator can obtain new ones, such as after a session expired, the request is sent once more.
+// Transient failures are retried with backoff as configured on the client.
+func (c *Client) send(
+	ctx context.Context,
+	method string,
+	urlAddress string,
+	data url.Values,
+	paginationVars *PaginationVars,
+	filter string,
+) (*http.Response, error) {
+	reauthenticated := false
+
+	for retry := 0; ; {
+		req, err := c.newRequest(ctx, method, urlAddress, data, paginationVars, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		authorization, err := c.auth.Authorization(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", authorization)
+
+		rawResponse, err := c.do(req)
+		if err != nil {
+			if ctx.Err() != nil || retry >= c.maxRetries || !isRetryable(method, 0) {
+				return nil, err
+			}
+
+			if err := wait(ctx, c.retryDelay(retry, nil)); err != nil {
+				return nil, err
+			}
+
+			retry++
+			continue
+		}
+
+		if rawResponse.StatusCode == http.StatusUnauthorized && !reauthenticated && c.auth.Expire(c, authorization) {
+			_ = rawResponse.Body.Close()
+			reauthenticated = true
+			continue
+		}
+
+		if retry < c.maxRetries && isRetryable(method, rawResponse.StatusCode) {
+			delay := c.retryDelay(retry, rawResponse)
+			_ = rawResponse.Body.Close()
+
+			if err := wait(ctx, delay); err != nil {
+				return nil, err
+			}
+
+			retry++
+			continue
+		}
+
+		return rawResponse, nil
+	}
+}
+
+func (c *Client) newRequest(
+	ctx context.Context,
+	method string,
+	urlAddress string,
+	data url.Values,
+	paginationVars *PaginationVars,
+	filter string,
+) (*http.Request, error) {
 	var body strings.Reader
 
 	if data != nil {
@@ -372,11 +1227,11 @@ func (c *Client) doRequest(
 
 	req, err := http.NewRequestWithContext(ctx, method, urlAddress, &body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// setup query params
-	queryParams := url.Values{}
+	// setup query params, keeping the ones already part of the URL
+	queryParams := req.URL.Query()
 	setupQueryParams(&queryParams)
 	setupPagination(&queryParams, paginationVars)
 	setupFiltering(&queryParams, filter)
@@ -387,22 +1242,6 @@ func (c *Client) doRequest(
 
 	// setup headers
 	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", c.Auth)
 
-	rawResponse, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer rawResponse.Body.Close()
-
-	if rawResponse.StatusCode >= 300 {
-		return status.Error(codes.Code(rawResponse.StatusCode), "Request failed")
-	}
-
-	if err := json.NewDecoder(rawResponse.Body).Decode(&resourceResponse); err != nil {
-		return err
-	}
-
-	return nil
+	return req, nil
 }
@@ -0,0 +1,47 @@
+package splunk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandlePagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []string
+		offset     int
+		total      int
+		wantValues []string
+		wantNext   string
+	}{
+		{name: "first of several pages", values: []string{"a", "b"}, offset: 0, total: 5, wantValues: []string{"a", "b"}, wantNext: "2"},
+		{name: "middle page", values: []string{"c", "d"}, offset: 2, total: 5, wantValues: []string{"c", "d"}, wantNext: "4"},
+		{name: "last page", values: []string{"e"}, offset: 4, total: 5, wantValues: []string{"e"}, wantNext: ""},
+		{name: "single page", values: []string{"a", "b"}, offset: 0, total: 2, wantValues: []string{"a", "b"}, wantNext: ""},
+		{name: "short page before the end", values: []string{"a"}, offset: 0, total: 3, wantValues: []string{"a"}, wantNext: "1"},
+		{name: "empty page", values: nil, offset: 4, total: 10, wantValues: nil, wantNext: ""},
+		{name: "no results", values: nil, offset: 0, total: 0, wantValues: nil, wantNext: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := &Response[string]{
+				Values:         tt.values,
+				PaginationData: PaginationData{Total: tt.total, Offset: tt.offset},
+			}
+
+			values, next, err := handlePagination(response)
+			if err != nil {
+				t.Fatalf("handlePagination() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Errorf("handlePagination() values = %v, want %v", values, tt.wantValues)
+			}
+
+			if next != tt.wantNext {
+				t.Errorf("handlePagination() next = %q, want %q", next, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package splunk
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetSHClusterConfig returns the search head clustering configuration of the instance.
+func (c *Client) GetSHClusterConfig(ctx context.Context) (*SHClusterConfig, error) {
+	var configResponse Response[SHClusterConfig]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(SHClusterConfigURL),
+		&configResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configResponse.Values) == 0 {
+		return nil, fmt.Errorf("search head clustering configuration not found")
+	}
+
+	return &configResponse.Values[0], nil
+}
+
+// GetSHClusterMembers returns the members of the search head cluster the instance is a member of.
+func (c *Client) GetSHClusterMembers(ctx context.Context, getMembersVars PaginationVars) ([]SHClusterMember, string, error) {
+	var membersResponse Response[SHClusterMember]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(SHClusterMembersURL),
+		&membersResponse,
+		&getMembersVars,
+		"",
+	)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&membersResponse)
+}
+
+// GetSHClusterCaptain returns the current captain of the search head cluster the instance is a member of.
+func (c *Client) GetSHClusterCaptain(ctx context.Context) (*SHClusterCaptain, error) {
+	var captainResponse Response[SHClusterCaptain]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(SHClusterCaptainURL),
+		&captainResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(captainResponse.Values) == 0 {
+		return nil, fmt.Errorf("search head cluster captain not found")
+	}
+
+	return &captainResponse.Values[0], nil
+}
+
+// GetClusterConfig returns the indexer clustering configuration of the instance.
+func (c *Client) GetClusterConfig(ctx context.Context) (*ClusterConfig, error) {
+	var configResponse Response[ClusterConfig]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(ClusterConfigURL),
+		&configResponse,
+		nil,
+		"",
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(configResponse.Values) == 0 {
+		return nil, fmt.Errorf("indexer clustering configuration not found")
+	}
+
+	return &configResponse.Values[0], nil
+}
+
+// GetClusterPeers returns the indexers of the cluster the instance manages.
+func (c *Client) GetClusterPeers(ctx context.Context, getPeersVars PaginationVars) ([]ClusterPeer, string, error) {
+	var peersResponse Response[ClusterPeer]
+
+	err := c.get(
+		ctx,
+		c.CreateUrl(ClusterManagerPeersURL),
+		&peersResponse,
+		&getPeersVars,
+		"",
+	)
+
+	// managers older than Splunk 9.0 only know the `master` endpoint
+	if isNotFound(err) {
+		err = c.get(
+			ctx,
+			c.CreateUrl(ClusterMasterPeersURL),
+			&peersResponse,
+			&getPeersVars,
+			"",
+		)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return handlePagination(&peersResponse)
+}
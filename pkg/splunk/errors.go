@@ -0,0 +1,114 @@
+package splunk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxErrorBodySize caps how much of an error response is read looking for messages.
+const maxErrorBodySize = 64 * 1024
+
+// Message is an entry of the `messages` list Splunk returns along with failed requests.
+type Message struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type messagesResponse struct {
+	Messages []Message `json:"messages"`
+}
+
+// APIError is returned for requests the management API answered with a status of 300 or above.
+// It converts to a gRPC status, so callers can rely on status.Code to tell failures apart.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Endpoint   string
+
+	// Type and Text come from the first message of the response, if any.
+	Type string
+	Text string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s failed with status %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.Text != "" {
+		msg += fmt.Sprintf(" (%s: %s)", e.Type, e.Text)
+	}
+
+	return msg
+}
+
+// Code maps the HTTP status of the response to the closest gRPC code.
+func (e *APIError) Code() codes.Code {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Unknown
+	}
+}
+
+func (e *APIError) GRPCStatus() *status.Status {
+	return status.New(e.Code(), e.Error())
+}
+
+// newAPIError builds an APIError from a failed response, reading the Splunk messages from its body.
+func newAPIError(rawResponse *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: rawResponse.StatusCode,
+	}
+
+	if rawResponse.Request != nil {
+		apiErr.Method = rawResponse.Request.Method
+		apiErr.Endpoint = rawResponse.Request.URL.Path
+	}
+
+	body, err := io.ReadAll(io.LimitReader(rawResponse.Body, maxErrorBodySize))
+	if err != nil {
+		return apiErr
+	}
+
+	// not every failure carries messages, such as errors of a proxy in front of Splunk
+	var response messagesResponse
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Messages) == 0 {
+		return apiErr
+	}
+
+	apiErr.Type = response.Messages[0].Type
+	apiErr.Text = response.Messages[0].Text
+
+	return apiErr
+}
+
+// isNotFound reports whether a request failed because the target doesn't exist.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
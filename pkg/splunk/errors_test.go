@@ -0,0 +1,46 @@
+package splunk
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIErrorCode(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       codes.Code
+	}{
+		{http.StatusBadRequest, codes.InvalidArgument},
+		{http.StatusUnauthorized, codes.Unauthenticated},
+		{http.StatusForbidden, codes.PermissionDenied},
+		{http.StatusNotFound, codes.NotFound},
+		{http.StatusMethodNotAllowed, codes.Unimplemented},
+		{http.StatusNotImplemented, codes.Unimplemented},
+		{http.StatusConflict, codes.AlreadyExists},
+		{http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{http.StatusTooManyRequests, codes.ResourceExhausted},
+		{http.StatusInternalServerError, codes.Internal},
+		{http.StatusBadGateway, codes.Unavailable},
+		{http.StatusServiceUnavailable, codes.Unavailable},
+		{http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{http.StatusMovedPermanently, codes.Unknown},
+		{http.StatusTeapot, codes.Unknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			err := &APIError{StatusCode: tt.statusCode, Method: http.MethodGet, Endpoint: UsersBaseURL}
+
+			if got := err.Code(); got != tt.want {
+				t.Errorf("Code() = %v, want %v", got, tt.want)
+			}
+
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("status.Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package splunk
 
+import "strings"
+
 type BaseResource struct {
 	Id  string `json:"id"`
 	ACL ACL    `json:"acl"`
@@ -10,8 +12,12 @@ type User struct {
 	Name    string `json:"name"`
 	Content struct {
 		Email        string   `json:"email"`
+		RealName     string   `json:"realname"`
 		Roles        []string `json:"roles"`
 		Capabilities []string `json:"capabilities"`
+		LockedOut    bool     `json:"locked-out"`
+		Type         string   `json:"type"`
+		DefaultApp   string   `json:"defaultApp"`
 	} `json:"content"`
 }
 
@@ -22,6 +28,11 @@ type Role struct {
 	Content struct {
 		Capabilities         []string `json:"capabilities"`
 		ImportedCapabilities []string `json:"imported_capabilities"`
+		ImportedRoles        []string `json:"imported_roles"`
+		SrchIndexesAllowed   []string `json:"srchIndexesAllowed"`
+		SrchIndexesDefault   []string `json:"srchIndexesDefault"`
+		DeleteIndexesAllowed []string `json:"deleteIndexesAllowed"`
+		SrchFilter           string   `json:"srchFilter"`
 	} `json:"content"`
 }
 
@@ -34,6 +45,98 @@ type Application struct {
 	} `json:"content"`
 }
 
+type Index struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		DataType string `json:"datatype"`
+		Disabled bool   `json:"disabled"`
+	} `json:"content"`
+}
+
+type Token struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Claims struct {
+			Subject        string `json:"sub"`
+			Audience       string `json:"aud"`
+			IssuedAt       int64  `json:"iat"`
+			ExpirationTime int64  `json:"exp"`
+		} `json:"claims"`
+		LastUsed   int64  `json:"lastUsed"`
+		LastUsedIP string `json:"lastUsedIp"`
+		Status     string `json:"status"`
+	} `json:"content"`
+}
+
+// KnowledgeObject is a saved search, alert or view. Fields that don't apply to a kind of object are left empty.
+type KnowledgeObject struct {
+	BaseResource
+	Name    string `json:"name"`
+	Author  string `json:"author"`
+	Content struct {
+		Label        string `json:"label"`
+		Disabled     bool   `json:"disabled"`
+		IsScheduled  bool   `json:"is_scheduled"`
+		CronSchedule string `json:"cron_schedule"`
+		AlertType    string `json:"alert_type"`
+		IsDashboard  bool   `json:"isDashboard"`
+	} `json:"content"`
+}
+
+// IsAlert reports whether a saved search triggers on its results rather than on every run.
+func (k *KnowledgeObject) IsAlert() bool {
+	return k.Content.AlertType != "" && k.Content.AlertType != "always"
+}
+
+// SAMLGroup maps a group asserted by the SAML identity provider to the Splunk roles its members get.
+type SAMLGroup struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Roles []string `json:"roles"`
+	} `json:"content"`
+}
+
+// LDAPStrategy is an LDAP server Splunk authenticates users against.
+type LDAPStrategy struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Host        string `json:"host"`
+		UserBaseDN  string `json:"userBaseDN"`
+		GroupBaseDN string `json:"groupBaseDN"`
+	} `json:"content"`
+}
+
+// LDAPGroup is a group of an LDAP strategy, along with the users in it and the Splunk roles it maps to.
+type LDAPGroup struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Roles []string `json:"roles"`
+		Users []string `json:"users"`
+	} `json:"content"`
+}
+
+// Strategy returns the LDAP strategy the group belongs to.
+func (g *LDAPGroup) Strategy() string {
+	strategy, _, _ := strings.Cut(g.Name, LDAPGroupSeparator)
+
+	return strategy
+}
+
+// GroupName returns the name of the group within its LDAP strategy, which may itself contain commas.
+func (g *LDAPGroup) GroupName() string {
+	_, group, found := strings.Cut(g.Name, LDAPGroupSeparator)
+	if !found {
+		return g.Name
+	}
+
+	return group
+}
+
 type Capability struct {
 	BaseResource
 	Name    string `json:"name"`
@@ -42,9 +145,101 @@ type Capability struct {
 	} `json:"content"`
 }
 
+// Lockout is a stanza of the conf file in which the connector saves the roles of locked users.
+type Lockout struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Roles string `json:"roles"`
+	} `json:"content"`
+}
+
+// SavedRoles returns the roles the user had before being locked.
+func (l *Lockout) SavedRoles() []string {
+	var roles []string
+	for _, role := range strings.Split(l.Content.Roles, LockoutRolesSeparator) {
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles
+}
+
+// SHClusterConfig is the search head clustering configuration of an instance.
+type SHClusterConfig struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Disabled bool   `json:"disabled"`
+		MgmtURI  string `json:"mgmt_uri"`
+	} `json:"content"`
+}
+
+// SHClusterMember is a member of a search head cluster, as seen by any of its members.
+type SHClusterMember struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Label   string `json:"label"`
+		MgmtURI string `json:"mgmt_uri"`
+		Status  string `json:"status"`
+		Site    string `json:"site"`
+	} `json:"content"`
+}
+
+// SHClusterCaptain is the member of a search head cluster that coordinates it and replicates configuration changes.
+type SHClusterCaptain struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Label   string `json:"label"`
+		MgmtURI string `json:"mgmt_uri"`
+	} `json:"content"`
+}
+
+// ClusterConfig is the indexer clustering configuration of an instance.
+type ClusterConfig struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Mode       string `json:"mode"`
+		ManagerURI string `json:"manager_uri"`
+		MasterURI  string `json:"master_uri"`
+	} `json:"content"`
+}
+
+// IsManager reports whether the instance is the manager of its indexer cluster.
+func (c *ClusterConfig) IsManager() bool {
+	return c.Content.Mode == ClusterModeManager || c.Content.Mode == ClusterModeMaster
+}
+
+// Manager returns the management URI of the cluster manager of the instance, named `master_uri` before Splunk 9.0.
+func (c *ClusterConfig) Manager() string {
+	if c.Content.ManagerURI != "" {
+		return c.Content.ManagerURI
+	}
+
+	return c.Content.MasterURI
+}
+
+// ClusterPeer is an indexer of an indexer cluster, as seen by its manager.
+type ClusterPeer struct {
+	BaseResource
+	Name    string `json:"name"`
+	Content struct {
+		Label        string `json:"label"`
+		HostPortPair string `json:"host_port_pair"`
+		Status       string `json:"status"`
+		Site         string `json:"site"`
+	} `json:"content"`
+}
+
 type ACL struct {
-	App   string `json:"app"`
-	Perms struct {
+	App     string `json:"app"`
+	Owner   string `json:"owner"`
+	Sharing string `json:"sharing"`
+	Perms   struct {
 		Read  []string `json:"read"`
 		Write []string `json:"write"`
 	} `json:"perms"`
@@ -0,0 +1,155 @@
+package splunk
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRetries            = 3
+	DefaultRetryBackoff          = time.Second
+	DefaultMaxConcurrentRequests = 5
+
+	// maxRetryBackoff caps the exponential backoff, but not delays asked for with Retry-After.
+	maxRetryBackoff = 30 * time.Second
+)
+
+// ClientOption configures optional behaviour of a Client.
+type ClientOption func(*Client)
+
+// WithRetries retries transient failures up to maxRetries times, waiting
+// exponentially longer between attempts starting from backoff. A backoff of 0 retries right away.
+func WithRetries(maxRetries int, backoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithMaxConcurrentRequests caps the requests in flight to the deployment of the client.
+// A limit of 0 or less leaves requests unlimited.
+func WithMaxConcurrentRequests(limit int) ClientOption {
+	return func(c *Client) {
+		if limit > 0 {
+			c.requestSlots = make(chan struct{}, limit)
+		}
+	}
+}
+
+// isRetryable reports whether a failed request can be sent again. Reads are retried on
+// connection errors and on statuses Splunk and its load balancers return while busy. Writes
+// are only retried when rate limited, since a busy load balancer may have passed them on already.
+func isRetryable(method string, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case 0, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method == http.MethodGet
+	default:
+		return false
+	}
+}
+
+// retryDelay returns how long to wait before the given retry. The server's Retry-After
+// header wins, otherwise the backoff doubles with each retry and is jittered so that
+// concurrent requests don't come back all at once.
+func (c *Client) retryDelay(retry int, rawResponse *http.Response) time.Duration {
+	if rawResponse != nil {
+		if delay, ok := parseRetryAfter(rawResponse.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	if c.retryBackoff <= 0 {
+		return 0
+	}
+
+	// the shift overflows into negative durations after enough retries
+	backoff := c.retryBackoff << retry
+	if backoff <= 0 || backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+
+	// #nosec G404 -- jitter doesn't need a secure source of randomness.
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// wait blocks for the given delay, or until the context is done.
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do sends a request once a request slot is free. The slot is held until the response body is closed.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.requestSlots == nil {
+		return c.httpClient.Do(req)
+	}
+
+	select {
+	case c.requestSlots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+
+	release := func() { <-c.requestSlots }
+
+	rawResponse, err := c.httpClient.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	rawResponse.Body = &slotBody{ReadCloser: rawResponse.Body, release: release}
+
+	return rawResponse, nil
+}
+
+// slotBody frees the request slot of a response once its body is closed.
+type slotBody struct {
+	io.ReadCloser
+
+	once    sync.Once
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+
+	return err
+}
@@ -0,0 +1,77 @@
+package splunk
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		method     string
+		statusCode int
+		want       bool
+	}{
+		{http.MethodGet, 0, true},
+		{http.MethodGet, http.StatusTooManyRequests, true},
+		{http.MethodGet, http.StatusBadGateway, true},
+		{http.MethodGet, http.StatusServiceUnavailable, true},
+		{http.MethodGet, http.StatusGatewayTimeout, true},
+		{http.MethodGet, http.StatusInternalServerError, false},
+		{http.MethodGet, http.StatusNotFound, false},
+		{http.MethodPost, http.StatusTooManyRequests, true},
+		{http.MethodPost, 0, false},
+		{http.MethodPost, http.StatusBadGateway, false},
+		{http.MethodPost, http.StatusServiceUnavailable, false},
+		{http.MethodPost, http.StatusGatewayTimeout, false},
+		{http.MethodDelete, http.StatusTooManyRequests, true},
+		{http.MethodDelete, http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+strconv.Itoa(tt.statusCode), func(t *testing.T) {
+			if got := isRetryable(tt.method, tt.statusCode); got != tt.want {
+				t.Errorf("isRetryable(%s, %d) = %v, want %v", tt.method, tt.statusCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	tests := []struct {
+		name     string
+		backoff  time.Duration
+		retry    int
+		response *http.Response
+		min, max time.Duration
+	}{
+		{name: "first retry", backoff: time.Second, retry: 0, min: 500 * time.Millisecond, max: time.Second},
+		{name: "doubles", backoff: time.Second, retry: 2, min: 2 * time.Second, max: 4 * time.Second},
+		{name: "capped", backoff: time.Second, retry: 10, min: maxRetryBackoff / 2, max: maxRetryBackoff},
+		{name: "overflow is capped", backoff: time.Second, retry: 62, min: maxRetryBackoff / 2, max: maxRetryBackoff},
+		{name: "zero backoff", backoff: 0, retry: 3, min: 0, max: 0},
+		{name: "retry after seconds", backoff: time.Second, retry: 0, response: retryAfter("120"), min: 2 * time.Minute, max: 2 * time.Minute},
+		{name: "retry after wins over zero backoff", backoff: 0, retry: 0, response: retryAfter("5"), min: 5 * time.Second, max: 5 * time.Second},
+		{name: "retry after in the past", backoff: time.Second, retry: 0, response: retryAfter("Mon, 02 Jan 2006 15:04:05 GMT"), min: 0, max: 0},
+		{name: "invalid retry after", backoff: time.Second, retry: 0, response: retryAfter("soon"), min: 500 * time.Millisecond, max: time.Second},
+		{name: "negative retry after", backoff: time.Second, retry: 0, response: retryAfter("-1"), min: 500 * time.Millisecond, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{retryBackoff: tt.backoff}
+
+			for i := 0; i < 20; i++ {
+				got := c.retryDelay(tt.retry, tt.response)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("retryDelay(%d) = %v, want between %v and %v", tt.retry, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}